@@ -13,6 +13,7 @@ type ICommand interface {
 	Aggr(...*AggrItem) ICommand
 	Insert(...string) ICommand
 	Update(...string) ICommand
+	Modify(...*UpdateItem) ICommand
 	Delete() ICommand
 	Save() ICommand
 
@@ -72,6 +73,12 @@ func (b *CommandBase) Update(fields ...string) ICommand {
 	return b
 }
 
+// Modify defines update expressions such as increment, push or unset. It can be combined with Update
+func (b *CommandBase) Modify(updateitems ...*UpdateItem) ICommand {
+	b.items = append(b.items, &QueryItem{QueryModify, updateitems})
+	return b
+}
+
 func (b *CommandBase) Delete() ICommand {
 	b.items = append(b.items, &QueryItem{QueryDelete, true})
 	return b
@@ -92,8 +99,16 @@ func (b *CommandBase) Skip(n int) ICommand {
 	return b
 }
 
+// Command defines a driver specific command. From and Where items defined before the command
+// are kept so driver can run the command against a table, any other items will be removed
 func (b *CommandBase) Command(command string, data interface{}) ICommand {
-	b.items = []*QueryItem{&QueryItem{QueryCommand, toolkit.M{}.Set("command", command).Set("data", data)}}
+	items := []*QueryItem{}
+	for _, item := range b.items {
+		if item.Op == QueryFrom || item.Op == QueryWhere {
+			items = append(items, item)
+		}
+	}
+	b.items = append(items, &QueryItem{QueryCommand, toolkit.M{}.Set("command", command).Set("data", data)})
 	return b
 }
 
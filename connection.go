@@ -34,6 +34,11 @@ type IConnection interface {
 	ObjectNames(ObjTypeEnum) []string
 	ValidateTable(interface{}, bool) error
 	DropTable(string) error
+	Describe(string) (*TableSchema, error)
+
+	EnsureIndex(string, *Index) error
+	DropIndex(string, string) error
+	Indexes(string) ([]*Index, error)
 
 	SetThis(IConnection) IConnection
 	This() IConnection
@@ -94,6 +99,22 @@ func (b *ConnectionBase) DropTable(name string) error {
 	return toolkit.Errorf("DropTable is not yet implemented")
 }
 
+func (b *ConnectionBase) Describe(tablename string) (*TableSchema, error) {
+	return nil, toolkit.Errorf("Describe is not yet implemented")
+}
+
+func (b *ConnectionBase) EnsureIndex(tablename string, index *Index) error {
+	return toolkit.Errorf("EnsureIndex is not yet implemented")
+}
+
+func (b *ConnectionBase) DropIndex(tablename, indexname string) error {
+	return toolkit.Errorf("DropIndex is not yet implemented")
+}
+
+func (b *ConnectionBase) Indexes(tablename string) ([]*Index, error) {
+	return nil, toolkit.Errorf("Indexes is not yet implemented")
+}
+
 func (b *ConnectionBase) Prepare(cmd ICommand) (IQuery, error) {
 	var dbCmd interface{}
 
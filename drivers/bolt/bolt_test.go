@@ -0,0 +1,183 @@
+package bolt
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eaciit/toolkit"
+
+	"github.com/eaciit/dbflex"
+	"github.com/eaciit/dbflex/orm"
+	"github.com/eaciit/dbflex/testbase"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCRUD(t *testing.T) {
+	workpath, _ := ioutil.TempDir("", "dbflexbolt")
+	defer os.RemoveAll(workpath)
+
+	crud := testbase.NewCRUD(t, toolkit.Sprintf("bolt://localhost/%s", filepath.Join(workpath, "crud.db")), 1000, nil)
+	crud.RunTest()
+}
+
+func TestKeys(t *testing.T) {
+	Convey("Key encoding", t, func() {
+		So(string(encodeKey([]interface{}{"A", 10})), ShouldEqual, "A\x0010")
+		So(keyPart(float64(10)), ShouldEqual, keyPart(10))
+		So(keyPart(1.5), ShouldEqual, "1.5")
+
+		key, err := documentKey(toolkit.M{}.Set("Code", "P1").Set("line", 2), []string{"code", "line"})
+		So(err, ShouldBeNil)
+		So(string(key), ShouldEqual, "P1\x002")
+		_, err = documentKey(toolkit.M{}.Set("code", ""), []string{"code"})
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Key lookup", t, func() {
+		keys, ok := lookupKeys(dbflex.In("_id", "I1", "I2"), []string{"_id"})
+		So(ok, ShouldBeTrue)
+		So(len(keys), ShouldEqual, 2)
+
+		keys, ok = lookupKeys(dbflex.And(dbflex.Eq("line", 2), dbflex.Gt("qty", 1), dbflex.Eq("code", "P1")), []string{"code", "line"})
+		So(ok, ShouldBeTrue)
+		So(string(keys[0]), ShouldEqual, "P1\x002")
+
+		_, ok = lookupKeys(dbflex.Eq("code", "P1"), []string{"code", "line"})
+		So(ok, ShouldBeFalse)
+		_, ok = lookupKeys(dbflex.Or(dbflex.Eq("_id", "I1"), dbflex.Eq("_id", "I2")), []string{"_id"})
+		So(ok, ShouldBeFalse)
+	})
+
+	Convey("Key range", t, func() {
+		from, to := keyRange(dbflex.And(dbflex.Gte("_id", "B"), dbflex.Gt("_id", "C"), dbflex.Lt("_id", "F")), []string{"_id"})
+		So(string(from), ShouldEqual, "C")
+		So(string(to), ShouldEqual, "F")
+
+		from, to = keyRange(dbflex.Range("_id", "A", "D"), []string{"_id"})
+		So(string(from), ShouldEqual, "A")
+		So(string(to), ShouldEqual, "D")
+
+		from, to = keyRange(dbflex.Gt("_id", 10), []string{"_id"})
+		So(from, ShouldBeNil)
+		So(to, ShouldBeNil)
+	})
+}
+
+type product struct {
+	orm.DatamodelBase `json:"-"`
+	Code              string `json:"code"`
+	Name              string `json:"name"`
+	Qty               int    `json:"qty"`
+}
+
+func (p *product) TableName() string {
+	return "products"
+}
+
+func (p *product) Id() ([]string, []interface{}) {
+	return []string{"Code"}, []interface{}{p.Code}
+}
+
+func TestCommands(t *testing.T) {
+	workpath, _ := ioutil.TempDir("", "dbflexbolt")
+	defer os.RemoveAll(workpath)
+	connTxt := toolkit.Sprintf("bolt://localhost/%s", filepath.Join(workpath, "commands.db"))
+
+	Convey("Bolt commands", t, func() {
+		conn, err := dbflex.NewConnectionFromUri(connTxt, nil)
+		So(err, ShouldBeNil)
+		So(conn.Connect(), ShouldBeNil)
+		defer conn.Close()
+		defer conn.DropTable("items")
+		defer conn.DropTable("products")
+
+		for i := 1; i <= 5; i++ {
+			id, err := conn.Execute(dbflex.From("items").Insert(), toolkit.M{}.Set("data",
+				toolkit.M{}.Set("_id", toolkit.Sprintf("I%d", i)).Set("qty", i*10)))
+			So(err, ShouldBeNil)
+			So(id, ShouldEqual, toolkit.Sprintf("I%d", i))
+		}
+		_, err = conn.Execute(dbflex.From("items").Insert(), toolkit.M{}.Set("data", toolkit.M{}.Set("_id", "I1")))
+		So(err, ShouldNotBeNil)
+
+		Convey("Key lookup and range", func() {
+			So(conn.Cursor(dbflex.From("items").Select().Where(dbflex.Eq("_id", "I2")), nil).Count(), ShouldEqual, 1)
+			So(conn.Cursor(dbflex.From("items").Select().Where(dbflex.In("_id", "I2", "I4", "I9")), nil).Count(), ShouldEqual, 2)
+			So(conn.Cursor(dbflex.From("items").Select().
+				Where(dbflex.And(dbflex.Eq("_id", "I3"), dbflex.Gt("qty", 30))), nil).Count(), ShouldEqual, 0)
+
+			ms := []toolkit.M{}
+			cur := conn.Cursor(dbflex.From("items").Select().Where(dbflex.And(dbflex.Gt("_id", "I2"), dbflex.Lte("_id", "I4"))), nil)
+			So(cur.Fetchs(&ms, 0), ShouldBeNil)
+			So(len(ms), ShouldEqual, 2)
+			So(ms[0].Get("_id"), ShouldEqual, "I3")
+			So(ms[1].Get("_id"), ShouldEqual, "I4")
+		})
+
+		Convey("Update, save and delete", func() {
+			n, err := conn.Execute(dbflex.From("items").Where(dbflex.Gte("qty", 40)).Modify(dbflex.Inc("qty", 1)), nil)
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 2)
+
+			_, err = conn.Execute(dbflex.From("items").Save(), toolkit.M{}.Set("data", toolkit.M{}.Set("_id", "I1").Set("qty", 1)))
+			So(err, ShouldBeNil)
+
+			n, err = conn.Execute(dbflex.From("items").Where(dbflex.Lt("qty", 20)).Delete(), nil)
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 1)
+
+			ms := []toolkit.M{}
+			So(conn.Cursor(dbflex.From("items").Select().OrderBy("-qty"), nil).Fetchs(&ms, 0), ShouldBeNil)
+			So(len(ms), ShouldEqual, 4)
+			So(ms[0].GetInt("qty"), ShouldEqual, 51)
+		})
+
+		Convey("Update key field", func() {
+			n, err := conn.Execute(dbflex.From("items").Where(dbflex.Eq("_id", "I2")).Modify(dbflex.Set("_id", "I9")), nil)
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 1)
+			So(conn.Cursor(dbflex.From("items").Select().Where(dbflex.Eq("_id", "I2")), nil).Count(), ShouldEqual, 0)
+
+			m := toolkit.M{}
+			So(conn.Cursor(dbflex.From("items").Select().Where(dbflex.Eq("_id", "I9")), nil).Fetch(&m), ShouldBeNil)
+			So(m.GetInt("qty"), ShouldEqual, 20)
+
+			_, err = conn.Execute(dbflex.From("items").Where(dbflex.Eq("_id", "I3")).Modify(dbflex.Set("_id", "I4")), nil)
+			So(err, ShouldNotBeNil)
+			So(conn.Cursor(dbflex.From("items").Select().Where(dbflex.Eq("_id", "I3")), nil).Count(), ShouldEqual, 1)
+		})
+
+		Convey("Model keyed by its Id", func() {
+			p := &product{Code: "P1", Name: "Pen", Qty: 3}
+			So(orm.Save(conn, p), ShouldBeNil)
+			p.Qty = 5
+			So(orm.Save(conn, p), ShouldBeNil)
+
+			got := &product{Code: "P1"}
+			So(orm.Get(conn, got), ShouldBeNil)
+			So(got.Name, ShouldEqual, "Pen")
+			So(got.Qty, ShouldEqual, 5)
+
+			_, err := conn.Execute(dbflex.From("products").Insert(), toolkit.M{}.Set("data", toolkit.M{}.Set("_id", "X")))
+			So(err, ShouldNotBeNil)
+
+			So(orm.Delete(conn, got), ShouldBeNil)
+			So(orm.Get(conn, &product{Code: "P1"}), ShouldNotBeNil)
+		})
+
+		Convey("Tables and shared file", func() {
+			So(conn.ObjectNames(dbflex.ObjTypeTable), ShouldResemble, []string{"items"})
+
+			other, err := dbflex.NewConnectionFromUri(connTxt, nil)
+			So(err, ShouldBeNil)
+			So(other.Connect(), ShouldBeNil)
+			So(other.Cursor(dbflex.From("items").Select(), nil).Count(), ShouldEqual, 5)
+			other.Close()
+
+			So(conn.DropTable("items"), ShouldBeNil)
+			So(conn.ObjectNames(dbflex.ObjTypeTable), ShouldResemble, []string{})
+		})
+	})
+}
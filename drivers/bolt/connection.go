@@ -0,0 +1,159 @@
+package bolt
+
+import (
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/eaciit/toolkit"
+	bbolt "go.etcd.io/bbolt"
+
+	"github.com/eaciit/dbflex"
+)
+
+func init() {
+	//=== sample: bolt://localhost/usr/local/data/app.db or bolt://data/app.db
+	dbflex.RegisterDriver("bolt", func(si *dbflex.ServerInfo) dbflex.IConnection {
+		c := new(Connection)
+		c.ServerInfo = *si
+		c.SetThis(c)
+		c.SetFieldNameTag("json")
+		return c
+	})
+}
+
+// DefaultTimeout is how long opening a database file waits for the lock held by other process
+var DefaultTimeout = 5 * time.Second
+
+// metaBucket keeps key fields of each table
+var metaBucket = []byte("__dbflex_meta")
+
+// database is a bolt file opened by the process. Bolt locks the file exclusively,
+// so it is shared by all connections to the same file and closed by the last one
+type database struct {
+	db   *bbolt.DB
+	refs int
+}
+
+var (
+	databases      = map[string]*database{}
+	databasesMutex sync.Mutex
+)
+
+func openDatabase(filePath string) (*bbolt.DB, error) {
+	databasesMutex.Lock()
+	defer databasesMutex.Unlock()
+
+	if d, ok := databases[filePath]; ok {
+		d.refs++
+		return d.db, nil
+	}
+
+	db, err := bbolt.Open(filePath, 0644, &bbolt.Options{Timeout: DefaultTimeout})
+	if err != nil {
+		return nil, toolkit.Errorf("unable to open %s. %s", filePath, err.Error())
+	}
+	databases[filePath] = &database{db, 1}
+	return db, nil
+}
+
+func closeDatabase(filePath string) {
+	databasesMutex.Lock()
+	defer databasesMutex.Unlock()
+
+	if d, ok := databases[filePath]; ok {
+		d.refs--
+		if d.refs <= 0 {
+			d.db.Close()
+			delete(databases, filePath)
+		}
+	}
+}
+
+// Connection of bolt driver, each bucket of the file is a table and each record is a json document
+// keyed by its key fields
+type Connection struct {
+	dbflex.ConnectionBase
+
+	db       *bbolt.DB
+	filePath string
+}
+
+// Connect opens the database file, it is created if not exist yet
+func (c *Connection) Connect() error {
+	filePath := c.Database
+	if c.Host != "" && c.Host != "localhost" {
+		filePath = filepath.Join(c.Host, c.Database)
+	}
+	if filePath == "" {
+		return toolkit.Errorf("database file is not specified")
+	}
+
+	db, err := openDatabase(filePath)
+	if err != nil {
+		return err
+	}
+	c.db = db
+	c.filePath = filePath
+	return nil
+}
+
+func (c *Connection) State() string {
+	if c.db != nil {
+		return dbflex.StateConnected
+	}
+	return dbflex.StateUnknown
+}
+
+func (c *Connection) Close() {
+	if c.db != nil {
+		closeDatabase(c.filePath)
+		c.db = nil
+	}
+}
+
+func (c *Connection) NewQuery() dbflex.IQuery {
+	q := new(Query)
+	q.SetThis(q)
+	q.SetConnection(c)
+	return q
+}
+
+func (c *Connection) ObjectNames(dbflex.ObjTypeEnum) []string {
+	names := []string{}
+	if c.db == nil {
+		return names
+	}
+
+	c.db.View(func(tx *bbolt.Tx) error {
+		return tx.ForEach(func(name []byte, _ *bbolt.Bucket) error {
+			if string(name) != string(metaBucket) {
+				names = append(names, string(name))
+			}
+			return nil
+		})
+	})
+	sort.Strings(names)
+	return names
+}
+
+func (c *Connection) ValidateTable(interface{}, bool) error {
+	return nil
+}
+
+func (c *Connection) DropTable(name string) error {
+	if c.db == nil {
+		return toolkit.Errorf("connection is not yet established")
+	}
+
+	return c.db.Update(func(tx *bbolt.Tx) error {
+		if err := tx.DeleteBucket([]byte(name)); err != nil && err != bbolt.ErrBucketNotFound {
+			return toolkit.Errorf("unable to drop %s. %s", name, err.Error())
+		}
+		if meta := tx.Bucket(metaBucket); meta != nil {
+			return meta.Delete([]byte(name))
+		}
+		return nil
+	})
+}
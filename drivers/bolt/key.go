@@ -0,0 +1,233 @@
+package bolt
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eaciit/toolkit"
+	bbolt "go.etcd.io/bbolt"
+
+	"github.com/eaciit/dbflex"
+	"github.com/eaciit/dbflex/drivers/internal/docutil"
+)
+
+// IDField is key field of a document when data has no Id method
+const IDField = "_id"
+
+// keySeparator joins values of a composite key
+const keySeparator = "\x00"
+
+type entry struct {
+	key []byte
+	doc toolkit.M
+}
+
+// keyPart returns text of a key value, integral number is written without decimal
+// so the same key is produced from an int and from a json number
+func keyPart(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+			return strconv.FormatInt(int64(v), 10)
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return keyPart(float64(v))
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	}
+	return toolkit.Sprintf("%v", v)
+}
+
+func encodeKey(values []interface{}) []byte {
+	parts := make([]string, len(values))
+	for idx, v := range values {
+		parts[idx] = keyPart(v)
+	}
+	return []byte(strings.Join(parts, keySeparator))
+}
+
+// tableKeyFields returns key fields of a table, IDField if it is not yet defined
+func tableKeyFields(tx *bbolt.Tx, tablename string) []string {
+	fields := []string{IDField}
+	if meta := tx.Bucket(metaBucket); meta != nil {
+		if bs := meta.Get([]byte(tablename)); bs != nil {
+			json.Unmarshal(bs, &fields)
+		}
+	}
+	return fields
+}
+
+// setTableKeyFields keeps key fields of a table on its first write, later write should use the same key fields
+func setTableKeyFields(tx *bbolt.Tx, tablename string, fields []string) error {
+	meta, err := tx.CreateBucketIfNotExists(metaBucket)
+	if err != nil {
+		return err
+	}
+
+	bs, _ := json.Marshal(fields)
+	if existing := meta.Get([]byte(tablename)); existing != nil {
+		if !bytes.Equal(existing, bs) {
+			return toolkit.Errorf("table %s is keyed by %s, data is keyed by %s", tablename, existing, bs)
+		}
+		return nil
+	}
+	return meta.Put([]byte(tablename), bs)
+}
+
+// documentKey returns key of a document from its key fields
+func documentKey(doc toolkit.M, fields []string) ([]byte, error) {
+	values := make([]interface{}, len(fields))
+	for idx, field := range fields {
+		v, ok := docutil.GetPath(doc, field)
+		if !ok || v == nil || v == "" {
+			return nil, toolkit.Errorf("key field %s has no value", field)
+		}
+		values[idx] = v
+	}
+	return encodeKey(values), nil
+}
+
+// scan returns documents of a bucket that match the filter. Equality and in on the key are read directly,
+// range on a string key seeks the bucket cursor, other filter scans the whole bucket
+func scan(b *bbolt.Bucket, keyFields []string, filter *dbflex.Filter) ([]entry, error) {
+	entries := []entry{}
+	if b == nil {
+		return entries, nil
+	}
+
+	add := func(k, v []byte) error {
+		doc := toolkit.M{}
+		if err := json.Unmarshal(v, &doc); err != nil {
+			return toolkit.Errorf("unable to parse %s. %s", string(k), err.Error())
+		}
+		match, err := filter.Match(doc)
+		if err != nil {
+			return err
+		}
+		if match {
+			entries = append(entries, entry{append([]byte{}, k...), doc})
+		}
+		return nil
+	}
+
+	if keys, ok := lookupKeys(filter, keyFields); ok {
+		for _, k := range keys {
+			if v := b.Get(k); v != nil {
+				if err := add(k, v); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return entries, nil
+	}
+
+	cursor := b.Cursor()
+	from, to := keyRange(filter, keyFields)
+	k, v := cursor.First()
+	if from != nil {
+		k, v = cursor.Seek(from)
+	}
+	for ; k != nil; k, v = cursor.Next() {
+		if to != nil && bytes.Compare(k, to) > 0 {
+			break
+		}
+		if err := add(k, v); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// lookupKeys returns keys to be read when filter is equality or in on the key
+func lookupKeys(f *dbflex.Filter, keyFields []string) ([][]byte, bool) {
+	if f == nil {
+		return nil, false
+	}
+
+	switch f.Op {
+	case dbflex.OpEq:
+		if len(keyFields) == 1 && f.Field == keyFields[0] {
+			return [][]byte{encodeKey([]interface{}{f.Value})}, true
+		}
+
+	case dbflex.OpIn:
+		if len(keyFields) == 1 && f.Field == keyFields[0] {
+			keys := [][]byte{}
+			rv := reflect.ValueOf(f.Value)
+			for idx := 0; rv.Kind() == reflect.Slice && idx < rv.Len(); idx++ {
+				keys = append(keys, encodeKey([]interface{}{rv.Index(idx).Interface()}))
+			}
+			return keys, true
+		}
+
+	case dbflex.OpAnd:
+		values := make([]interface{}, len(keyFields))
+		found := 0
+		for _, item := range f.Items {
+			if keys, ok := lookupKeys(item, keyFields); ok {
+				return keys, true
+			}
+			for idx, field := range keyFields {
+				if item.Op == dbflex.OpEq && item.Field == field && values[idx] == nil {
+					values[idx] = item.Value
+					found++
+				}
+			}
+		}
+		if found == len(keyFields) {
+			return [][]byte{encodeKey(values)}, true
+		}
+	}
+	return nil, false
+}
+
+// keyRange returns inclusive bound of a string key, nil means it has no bound.
+// The bound may include more keys than the filter, each document is still matched against the filter
+func keyRange(f *dbflex.Filter, keyFields []string) ([]byte, []byte) {
+	if f == nil || len(keyFields) != 1 {
+		return nil, nil
+	}
+
+	var from, to []byte
+	switch f.Op {
+	case dbflex.OpGt, dbflex.OpGte:
+		if s, ok := f.Value.(string); ok && f.Field == keyFields[0] {
+			from = []byte(s)
+		}
+
+	case dbflex.OpLt, dbflex.OpLte:
+		if s, ok := f.Value.(string); ok && f.Field == keyFields[0] {
+			to = []byte(s)
+		}
+
+	case dbflex.OpRange:
+		values, ok := f.Value.([]interface{})
+		if ok && len(values) == 2 && f.Field == keyFields[0] {
+			s0, ok0 := values[0].(string)
+			s1, ok1 := values[1].(string)
+			if ok0 && ok1 {
+				from, to = []byte(s0), []byte(s1)
+			}
+		}
+
+	case dbflex.OpAnd:
+		for _, item := range f.Items {
+			itemFrom, itemTo := keyRange(item, keyFields)
+			if itemFrom != nil && (from == nil || bytes.Compare(itemFrom, from) > 0) {
+				from = itemFrom
+			}
+			if itemTo != nil && (to == nil || bytes.Compare(itemTo, to) < 0) {
+				to = itemTo
+			}
+		}
+	}
+	return from, to
+}
@@ -0,0 +1,211 @@
+package bolt
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/eaciit/toolkit"
+	bbolt "go.etcd.io/bbolt"
+
+	"github.com/eaciit/dbflex"
+	"github.com/eaciit/dbflex/drivers/internal/docutil"
+)
+
+type Query struct {
+	dbflex.QueryBase
+}
+
+// BuildFilter returns the filter as is, it is evaluated per document by the cursor
+func (q *Query) BuildFilter(f *dbflex.Filter) (interface{}, error) {
+	return f, nil
+}
+
+func (q *Query) BuildCommand() (interface{}, error) {
+	return nil, nil
+}
+
+func (q *Query) database() (*bbolt.DB, string, error) {
+	db := q.Connection().(*Connection).db
+	if db == nil {
+		return nil, "", toolkit.Errorf("connection is not yet established")
+	}
+
+	tablename := q.Config(dbflex.ConfigKeyTableName, "").(string)
+	if tablename == "" {
+		return nil, "", toolkit.Errorf("no tablename is specified")
+	}
+	return db, tablename, nil
+}
+
+// Cursor reads matched documents in a single read transaction when the cursor is created or reset
+func (q *Query) Cursor(toolkit.M) dbflex.ICursor {
+	c := docutil.NewCursor(q)
+	db, tablename, err := q.database()
+	if err != nil {
+		c.SetError(err)
+		return c
+	}
+
+	return c.Load(func(filter *dbflex.Filter) ([]toolkit.M, error) {
+		var entries []entry
+		err := db.View(func(tx *bbolt.Tx) error {
+			var err error
+			entries, err = scan(tx.Bucket([]byte(tablename)), tableKeyFields(tx, tablename), filter)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		docs := make([]toolkit.M, len(entries))
+		for idx, e := range entries {
+			docs[idx] = e.doc
+		}
+		return docs, nil
+	})
+}
+
+// Execute runs a command in a bolt transaction, so a failed command leaves the table untouched.
+// Insert and save return value of the key, update and delete return number of affected documents
+func (q *Query) Execute(parm toolkit.M) (interface{}, error) {
+	cmdType := q.Config(dbflex.ConfigKeyCommandType, "").(string)
+	db, tablename, err := q.database()
+	if err != nil {
+		return nil, err
+	}
+
+	if cmdType == dbflex.QuerySelect {
+		return nil, toolkit.Errorf("select command should use cursor instead of execute")
+	}
+
+	var (
+		data      toolkit.M
+		keyFields []string
+	)
+	if parm != nil && parm.Has("data") {
+		if data, err = docutil.ToDocument(parm.Get("data")); err != nil {
+			return nil, toolkit.Errorf("unable to serialize data. %s", err.Error())
+		}
+		keyFields = docutil.ModelKeyFields(parm.Get("data"))
+	}
+
+	var result interface{}
+	err = db.Update(func(tx *bbolt.Tx) error {
+		if keyFields == nil {
+			keyFields = tableKeyFields(tx, tablename)
+		}
+
+		switch cmdType {
+		case dbflex.QueryInsert, dbflex.QuerySave:
+			if data == nil {
+				return toolkit.Errorf("%s fail, no data", cmdType)
+			}
+			if len(keyFields) == 1 && keyFields[0] == IDField {
+				if id, ok := data[IDField]; !ok || id == nil || id == "" {
+					data.Set(IDField, toolkit.RandomString(32))
+				}
+			}
+
+			key, err := documentKey(data, keyFields)
+			if err != nil {
+				return err
+			}
+			if err = setTableKeyFields(tx, tablename, keyFields); err != nil {
+				return err
+			}
+			b, err := tx.CreateBucketIfNotExists([]byte(tablename))
+			if err != nil {
+				return toolkit.Errorf("unable to create table %s. %s", tablename, err.Error())
+			}
+			if cmdType == dbflex.QueryInsert && b.Get(key) != nil {
+				return toolkit.Errorf("insert fail, duplicate key %s", strings.Replace(string(key), keySeparator, ",", -1))
+			}
+			if err = putDocument(b, key, data); err != nil {
+				return err
+			}
+
+			values := []interface{}{}
+			for _, field := range keyFields {
+				v, _ := docutil.GetPath(data, field)
+				values = append(values, v)
+			}
+			if len(values) == 1 {
+				result = values[0]
+			} else {
+				result = values
+			}
+			return nil
+
+		case dbflex.QueryUpdate:
+			updateItems := docutil.UpdateItems(q)
+			updates := docutil.UpdateValues(data, q.Config("fields", []string{}).([]string), keyFields)
+			if len(updates) == 0 && len(updateItems) == 0 {
+				return toolkit.Errorf("update need to have data or update items")
+			}
+
+			b := tx.Bucket([]byte(tablename))
+			filter, _ := q.Config(dbflex.ConfigKeyFilter, nil).(*dbflex.Filter)
+			entries, err := scan(b, keyFields, filter)
+			if err != nil {
+				return err
+			}
+			for _, e := range entries {
+				if err = docutil.Update(e.doc, updates, updateItems); err != nil {
+					return err
+				}
+
+				// an update item could change the key, the document is then moved to its new key
+				key, err := documentKey(e.doc, keyFields)
+				if err != nil {
+					return err
+				}
+				if !bytes.Equal(key, e.key) {
+					if b.Get(key) != nil {
+						return toolkit.Errorf("update fail, duplicate key %s", strings.Replace(string(key), keySeparator, ",", -1))
+					}
+					if err = b.Delete(e.key); err != nil {
+						return toolkit.Errorf("unable to delete %s. %s", string(e.key), err.Error())
+					}
+				}
+				if err = putDocument(b, key, e.doc); err != nil {
+					return err
+				}
+			}
+			result = len(entries)
+			return nil
+
+		case dbflex.QueryDelete:
+			b := tx.Bucket([]byte(tablename))
+			filter, _ := q.Config(dbflex.ConfigKeyFilter, nil).(*dbflex.Filter)
+			entries, err := scan(b, keyFields, filter)
+			if err != nil {
+				return err
+			}
+			for _, e := range entries {
+				if err = b.Delete(e.key); err != nil {
+					return toolkit.Errorf("unable to delete %s. %s", string(e.key), err.Error())
+				}
+			}
+			result = len(entries)
+			return nil
+		}
+
+		return toolkit.Errorf("unknown command: %s", cmdType)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func putDocument(b *bbolt.Bucket, key []byte, doc toolkit.M) error {
+	bs, err := json.Marshal(doc)
+	if err != nil {
+		return toolkit.Errorf("unable to serialize data. %s", err.Error())
+	}
+	if err = b.Put(key, bs); err != nil {
+		return toolkit.Errorf("unable to write %s. %s", string(key), err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package docutil
+
+import (
+	"strings"
+
+	"github.com/eaciit/dbflex"
+	"github.com/eaciit/toolkit"
+)
+
+// Aggregator computes aggregation per group of documents. Result has the same shape with
+// mongodb driver: _id contains group keys, and each group key and aggregation alias is a field
+type Aggregator struct {
+	aggrs       []*dbflex.AggrItem
+	groupFields []string
+	groupKeys   []string
+
+	groups  map[string]*aggrGroup
+	results []toolkit.M
+}
+
+type aggrGroup struct {
+	result toolkit.M
+	sums   map[string]float64
+	counts map[string]int
+}
+
+// NewAggregator creates an aggregator, documents are added one by one so they could be streamed
+func NewAggregator(aggrs []*dbflex.AggrItem, groupFields []string) *Aggregator {
+	a := new(Aggregator)
+	a.aggrs = aggrs
+	a.groupFields = groupFields
+	a.groupKeys = make([]string, len(groupFields))
+	for idx, g := range groupFields {
+		a.groupKeys[idx] = GroupKey(g)
+	}
+	a.groups = map[string]*aggrGroup{}
+	a.results = []toolkit.M{}
+	return a
+}
+
+// Aggregate computes aggregation of documents
+func Aggregate(docs []toolkit.M, aggrs []*dbflex.AggrItem, groupFields []string) []toolkit.M {
+	a := NewAggregator(aggrs, groupFields)
+	for _, doc := range docs {
+		a.Add(doc)
+	}
+	return a.Result()
+}
+
+// GroupKey returns name of a group field on the aggregation result
+func GroupKey(field string) string {
+	return strings.Replace(field, ".", "_", -1)
+}
+
+// Add adds a document into its group
+func (a *Aggregator) Add(doc toolkit.M) {
+	var id interface{} = ""
+	if len(a.groupFields) > 0 {
+		idm := toolkit.M{}
+		for idx, g := range a.groupFields {
+			v, _ := GetPath(doc, g)
+			idm.Set(a.groupKeys[idx], v)
+		}
+		id = idm
+	}
+
+	key := toolkit.JsonString(id)
+	group, ok := a.groups[key]
+	if !ok {
+		group = &aggrGroup{toolkit.M{}.Set("_id", id), map[string]float64{}, map[string]int{}}
+		if idm, isM := id.(toolkit.M); isM {
+			for k, v := range idm {
+				group.result.Set(k, v)
+			}
+		}
+		a.groups[key] = group
+		a.results = append(a.results, group.result)
+	}
+
+	for _, item := range a.aggrs {
+		v, _ := GetPath(doc, item.Field)
+		group.add(item, v)
+	}
+}
+
+// Result returns a document per group, in the order each group is found
+func (a *Aggregator) Result() []toolkit.M {
+	for _, group := range a.groups {
+		for _, item := range a.aggrs {
+			switch item.Op {
+			case dbflex.AggrSum:
+				group.result.Set(item.Alias, group.sums[item.Alias])
+			case dbflex.AggrAvg:
+				avg := float64(0)
+				if n := group.counts[item.Alias]; n > 0 {
+					avg = group.sums[item.Alias] / float64(n)
+				}
+				group.result.Set(item.Alias, avg)
+			case dbflex.AggrCount:
+				group.result.Set(item.Alias, group.counts[item.Alias])
+			}
+		}
+	}
+	return a.results
+}
+
+func (g *aggrGroup) add(item *dbflex.AggrItem, v interface{}) {
+	switch item.Op {
+	case dbflex.AggrCount:
+		g.counts[item.Alias]++
+
+	case dbflex.AggrSum, dbflex.AggrAvg:
+		if f, ok := dbflex.NumberValue(v); ok {
+			g.sums[item.Alias] += f
+			g.counts[item.Alias]++
+		}
+
+	case dbflex.AggrMin, dbflex.AggrMax:
+		if v == nil {
+			return
+		}
+		current, ok := g.result[item.Alias]
+		if !ok {
+			g.result.Set(item.Alias, v)
+			return
+		}
+		cmp := dbflex.CompareValue(v, current, nil)
+		if (item.Op == dbflex.AggrMin && cmp < 0) || (item.Op == dbflex.AggrMax && cmp > 0) {
+			g.result.Set(item.Alias, v)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package docutil
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/eaciit/toolkit"
+
+	"github.com/eaciit/dbflex"
+)
+
+// Source returns documents of a table that match the filter. The cursor owns the returned documents,
+// so source should return copies of the documents it keeps
+type Source func(filter *dbflex.Filter) ([]toolkit.M, error)
+
+// Cursor fetches documents that are read at once from a source, aggregation, sort, skip and take
+// are applied in process. Result is taken when the cursor is loaded or reset,
+// so later changes on the table do not affect the documents being fetched
+type Cursor struct {
+	dbflex.CursorBase
+
+	source Source
+
+	filter     *dbflex.Filter
+	sorts      []string
+	fields     []string
+	skip, take int
+	aggrs      []*dbflex.AggrItem
+	groups     []string
+
+	docs  []toolkit.M
+	count int
+	pos   int
+}
+
+// NewCursor creates a cursor of a prepared query, documents are read once Load is called
+func NewCursor(q dbflex.IQuery) *Cursor {
+	c := new(Cursor)
+	c.SetThis(c)
+	c.SetConnection(q.Connection())
+
+	parts := q.Config(dbflex.ConfigKeyGroupedQueryItems, dbflex.GroupedQueryItems{}).(dbflex.GroupedQueryItems)
+	if filter, ok := q.Config(dbflex.ConfigKeyFilter, nil).(*dbflex.Filter); ok {
+		c.filter = filter
+	}
+	c.fields = q.Config("fields", []string{}).([]string)
+	c.sorts = fieldsOf(parts[dbflex.QueryOrder])
+	c.groups = fieldsOf(parts[dbflex.QueryGroup])
+	if items, ok := parts[dbflex.QueryAggr]; ok {
+		c.aggrs = items[0].Value.([]*dbflex.AggrItem)
+	}
+	if items, ok := parts[dbflex.QuerySkip]; ok {
+		c.skip = items[0].Value.(int)
+	}
+	if items, ok := parts[dbflex.QueryTake]; ok {
+		c.take = items[0].Value.(int)
+	}
+	return c
+}
+
+func fieldsOf(items []*dbflex.QueryItem) []string {
+	var fields []string
+	for _, item := range items {
+		for _, field := range item.Value.([]string) {
+			if strings.TrimSpace(field) != "" {
+				fields = append(fields, strings.TrimSpace(field))
+			}
+		}
+	}
+	return fields
+}
+
+// Load reads the documents from source
+func (c *Cursor) Load(source Source) *Cursor {
+	c.source = source
+	c.SetError(c.load())
+	return c
+}
+
+func (c *Cursor) Reset() error {
+	if c.source == nil {
+		return c.Error()
+	}
+	c.SetError(c.load())
+	return c.Error()
+}
+
+func (c *Cursor) Fetch(out interface{}) error {
+	if c.Error() != nil {
+		return c.Error()
+	}
+	if c.pos >= len(c.docs) {
+		return toolkit.Error("EOF")
+	}
+
+	err := c.decode(c.docs[c.pos], out)
+	c.pos++
+	if c.CloseAfterFetch() {
+		c.Close()
+	}
+	return err
+}
+
+func (c *Cursor) Fetchs(result interface{}, n int) error {
+	if c.Error() != nil {
+		return c.Error()
+	}
+
+	v := reflect.TypeOf(result).Elem().Elem()
+	ivs := reflect.MakeSlice(reflect.SliceOf(v), 0, 0)
+	for read := 0; (n == 0 || read < n) && c.pos < len(c.docs); read++ {
+		ivp := reflect.New(v)
+		if v.Kind() == reflect.Map {
+			ivp.Elem().Set(reflect.MakeMap(v))
+		}
+		if err := c.decode(c.docs[c.pos], ivp.Interface()); err != nil {
+			return toolkit.Errorf("unable to serialize data. %s", err.Error())
+		}
+		ivs = reflect.Append(ivs, ivp.Elem())
+		c.pos++
+	}
+	reflect.ValueOf(result).Elem().Set(ivs)
+
+	if c.CloseAfterFetch() {
+		c.Close()
+	}
+	return nil
+}
+
+// Count returns number of documents that match the filter, or number of groups for aggregation.
+// Skip and take is not applied
+func (c *Cursor) Count() int {
+	return c.count
+}
+
+func (c *Cursor) Close() {
+	c.docs = nil
+	c.pos = 0
+}
+
+// load reads matched documents, or aggregation result, then applies sort, skip and take
+func (c *Cursor) load() error {
+	c.docs = nil
+	c.pos = 0
+	c.count = 0
+
+	docs, err := c.source(c.filter)
+	if err != nil {
+		return err
+	}
+
+	sorts := c.sorts
+	if len(c.aggrs) > 0 {
+		docs = Aggregate(docs, c.aggrs, c.groups)
+		sorts = make([]string, len(c.sorts))
+		for idx, s := range c.sorts {
+			sorts[idx] = GroupKey(s)
+		}
+	}
+	if len(sorts) > 0 {
+		sort.SliceStable(docs, func(i, j int) bool {
+			return CompareDocument(docs[i], docs[j], sorts) < 0
+		})
+	}
+
+	c.count = len(docs)
+	if c.skip > 0 {
+		skip := c.skip
+		if skip > len(docs) {
+			skip = len(docs)
+		}
+		docs = docs[skip:]
+	}
+	if c.take > 0 && c.take < len(docs) {
+		docs = docs[:c.take]
+	}
+	c.docs = docs
+	return nil
+}
+
+// decode writes a document into out, and keeps only selected fields if any.
+// Map output receives the document as is, other output is deserialized using json
+func (c *Cursor) decode(doc toolkit.M, out interface{}) error {
+	m := doc
+	if len(c.fields) > 0 {
+		m = toolkit.M{}
+		for _, field := range c.fields {
+			if v, ok := GetPath(doc, field); ok {
+				SetPath(m, field, v)
+			}
+		}
+	}
+
+	switch o := out.(type) {
+	case *toolkit.M:
+		*o = m
+		return nil
+	case *map[string]interface{}:
+		*o = m
+		return nil
+	}
+	return toolkit.Serde(m, out, "json")
+}
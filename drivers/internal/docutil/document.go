@@ -0,0 +1,182 @@
+package docutil
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eaciit/toolkit"
+)
+
+// ToDocument converts a struct or a map into a document. Field name follows json tag, nested struct and map
+// become document while time.Time and other scalar values are kept as is
+func ToDocument(data interface{}) (toolkit.M, error) {
+	m, ok := copyValue(reflect.ValueOf(data)).(toolkit.M)
+	if !ok {
+		return nil, toolkit.Errorf("data should be a struct or a map, got %T", data)
+	}
+	return m, nil
+}
+
+// ToOrderedDocument is ToDocument that also returns the field names, in struct order or sorted map keys
+func ToOrderedDocument(data interface{}) (toolkit.M, []string, error) {
+	rv := reflect.ValueOf(data)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			break
+		}
+		rv = rv.Elem()
+	}
+
+	fields := []string{}
+	if rv.Kind() == reflect.Struct {
+		if _, isTime := rv.Interface().(time.Time); !isTime {
+			m := toolkit.M{}
+			copyStruct(rv, m, &fields)
+			return m, fields, nil
+		}
+	}
+
+	m, ok := copyValue(rv).(toolkit.M)
+	if !ok {
+		return nil, nil, toolkit.Errorf("data should be a struct or a map, got %T", data)
+	}
+	for k := range m {
+		fields = append(fields, k)
+	}
+	sort.Strings(fields)
+	return m, fields, nil
+}
+
+// ModelKeyFields returns key fields of an orm model named by json tag, nil if data has no Id method
+func ModelKeyFields(data interface{}) []string {
+	model, ok := data.(interface {
+		Id() ([]string, []interface{})
+	})
+	if !ok {
+		return nil
+	}
+
+	fields, _ := model.Id()
+	rt := reflect.Indirect(reflect.ValueOf(data)).Type()
+	names := make([]string, len(fields))
+	for idx, field := range fields {
+		names[idx] = field
+		if sf, ok := rt.FieldByName(field); ok {
+			if tag := strings.Split(sf.Tag.Get("json"), ",")[0]; tag != "" && tag != "-" {
+				names[idx] = tag
+			}
+		}
+	}
+	return names
+}
+
+// CopyDocuments returns a deep copy of each document
+func CopyDocuments(docs []toolkit.M) []toolkit.M {
+	result := make([]toolkit.M, len(docs))
+	for idx, doc := range docs {
+		result[idx] = CopyOf(doc).(toolkit.M)
+	}
+	return result
+}
+
+// CopyOf returns a deep copy of a value, so a stored document never shares map or slice with caller
+func CopyOf(v interface{}) interface{} {
+	return copyValue(reflect.ValueOf(v))
+}
+
+func copyValue(rv reflect.Value) interface{} {
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return nil
+
+	case reflect.Struct:
+		if t, ok := rv.Interface().(time.Time); ok {
+			return t
+		}
+		m := toolkit.M{}
+		copyStruct(rv, m, nil)
+		return m
+
+	case reflect.Map:
+		if rv.IsNil() {
+			return nil
+		}
+		m := toolkit.M{}
+		for _, k := range rv.MapKeys() {
+			m[toolkit.ToString(k.Interface())] = copyValue(rv.MapIndex(k))
+		}
+		return m
+
+	case reflect.Slice:
+		if rv.IsNil() {
+			return nil
+		}
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			bs := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(bs), rv)
+			return bs
+		}
+		fallthrough
+
+	case reflect.Array:
+		values := make([]interface{}, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			values[i] = copyValue(rv.Index(i))
+		}
+		return values
+	}
+
+	return rv.Interface()
+}
+
+// copyStruct copies exported fields of a struct the way encoding/json does, embedded struct is flatten.
+// Name of each copied field is appended to names if it is not nil
+func copyStruct(rv reflect.Value, m toolkit.M, names *[]string) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		fv := rv.Field(i)
+
+		name, omitEmpty := field.Name, false
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			parts := strings.Split(tag, ",")
+			if parts[0] == "-" {
+				continue
+			}
+			if parts[0] != "" {
+				name = parts[0]
+			}
+			for _, opt := range parts[1:] {
+				omitEmpty = omitEmpty || opt == "omitempty"
+			}
+		} else if field.Anonymous {
+			if fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				copyStruct(fv, m, names)
+				continue
+			}
+		}
+
+		if !fv.CanInterface() || (omitEmpty && fv.IsZero()) {
+			continue
+		}
+		if _, exist := m[name]; !exist && names != nil {
+			*names = append(*names, name)
+		}
+		m[name] = copyValue(fv)
+	}
+}
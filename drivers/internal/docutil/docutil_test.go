@@ -0,0 +1,74 @@
+package docutil
+
+import (
+	"testing"
+
+	"github.com/eaciit/dbflex"
+	"github.com/eaciit/toolkit"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPath(t *testing.T) {
+	Convey("Dot path", t, func() {
+		m := toolkit.M{}.Set("Name", "Arief").Set("address", toolkit.M{}.Set("city", "Jakarta"))
+
+		v, ok := GetPath(m, "name")
+		So(ok, ShouldBeTrue)
+		So(v, ShouldEqual, "Arief")
+
+		SetPath(m, "NAME", "Bagus")
+		So(m["Name"], ShouldEqual, "Bagus")
+		SetPath(m, "address.zip", "10110")
+		SetPath(m, "office.city", "Bandung")
+		v, _ = GetPath(m, "address.zip")
+		So(v, ShouldEqual, "10110")
+		v, _ = GetPath(m, "office.city")
+		So(v, ShouldEqual, "Bandung")
+
+		UnsetPath(m, "address.City")
+		_, ok = GetPath(m, "address.city")
+		So(ok, ShouldBeFalse)
+		UnsetPath(m, "missing.city")
+		So(len(m), ShouldEqual, 3)
+	})
+
+	Convey("Compare document", t, func() {
+		a := toolkit.M{}.Set("grade", 1).Set("name", "b")
+		b := toolkit.M{}.Set("grade", 1).Set("name", "a")
+		So(CompareDocument(a, b, []string{"grade"}), ShouldEqual, 0)
+		So(CompareDocument(a, b, []string{"grade", "name"}), ShouldBeGreaterThan, 0)
+		So(CompareDocument(a, b, []string{"-name"}), ShouldBeLessThan, 0)
+	})
+
+	Convey("Update", t, func() {
+		data := toolkit.M{}.Set("_id", "E1").Set("name", "Arief").Set("salary", 100)
+		So(UpdateValues(data, nil, []string{"_id"}).Has("_id"), ShouldBeFalse)
+		So(UpdateValues(data, []string{"Salary"}, []string{"_id"}), ShouldResemble, toolkit.M{}.Set("Salary", 100))
+
+		m := toolkit.M{}.Set("_id", "E1").Set("salary", 50).Set("note", "x")
+		err := Update(m, toolkit.M{}.Set("name", "Bagus"), []*dbflex.UpdateItem{dbflex.Inc("salary", 10), dbflex.Unset("note")})
+		So(err, ShouldBeNil)
+		So(m, ShouldResemble, toolkit.M{}.Set("_id", "E1").Set("salary", 60).Set("name", "Bagus"))
+	})
+}
+
+func TestAggregate(t *testing.T) {
+	Convey("Aggregate by group", t, func() {
+		docs := []toolkit.M{
+			toolkit.M{}.Set("dept", toolkit.M{}.Set("code", "A")).Set("salary", 10),
+			toolkit.M{}.Set("dept", toolkit.M{}.Set("code", "B")).Set("salary", 20),
+			toolkit.M{}.Set("dept", toolkit.M{}.Set("code", "A")).Set("salary", 30).Set("note", "x"),
+			toolkit.M{}.Set("dept", toolkit.M{}.Set("code", "A")).Set("salary", "40"),
+		}
+		result := Aggregate(docs, []*dbflex.AggrItem{dbflex.NewAggrItem("total", dbflex.AggrSum, "salary"),
+			dbflex.NewAggrItem("low", dbflex.AggrMin, "salary"), dbflex.NewAggrItem("n", dbflex.AggrCount, "note")},
+			[]string{"dept.code"})
+		So(len(result), ShouldEqual, 2)
+		So(result[0].Get("dept_code"), ShouldEqual, "A")
+		So(result[0].Get("total"), ShouldEqual, 40)
+		So(result[0].Get("low"), ShouldEqual, 10)
+		So(result[0].Get("n"), ShouldEqual, 3)
+		So(result[1].Get("_id"), ShouldResemble, toolkit.M{}.Set("dept_code", "B"))
+	})
+}
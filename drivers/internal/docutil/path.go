@@ -0,0 +1,158 @@
+// Package docutil holds document helpers shared by drivers that evaluate commands in process
+package docutil
+
+import (
+	"strings"
+
+	"github.com/eaciit/dbflex"
+	"github.com/eaciit/toolkit"
+)
+
+// GetPath returns value of a dot path field. Key is case insensitive, and number on the path
+// is used as array index
+func GetPath(m map[string]interface{}, path string) (interface{}, bool) {
+	return dbflex.PathValue(m, path, nil)
+}
+
+// SetPath sets value of a dot path field, missing parent is created as a document
+func SetPath(m map[string]interface{}, path string, v interface{}) {
+	parts := strings.Split(path, ".")
+	current := m
+	for idx, part := range parts {
+		key, ok := MapKey(current, part)
+		if !ok {
+			key = part
+		}
+		if idx == len(parts)-1 {
+			current[key] = v
+			return
+		}
+
+		child, ok := current[key].(map[string]interface{})
+		if !ok {
+			if childM, isM := current[key].(toolkit.M); isM {
+				child = childM
+			} else {
+				child = map[string]interface{}{}
+				current[key] = child
+			}
+		}
+		current = child
+	}
+}
+
+// UnsetPath removes a dot path field
+func UnsetPath(m map[string]interface{}, path string) {
+	parts := strings.Split(path, ".")
+	current := m
+	if len(parts) > 1 {
+		v, _ := GetPath(m, strings.Join(parts[:len(parts)-1], "."))
+		switch pv := v.(type) {
+		case map[string]interface{}:
+			current = pv
+		case toolkit.M:
+			current = pv
+		default:
+			return
+		}
+	}
+	if key, ok := MapKey(current, parts[len(parts)-1]); ok {
+		delete(current, key)
+	}
+}
+
+// MapKey returns the key of m that has the name, case insensitive
+func MapKey(m map[string]interface{}, name string) (string, bool) {
+	if _, ok := m[name]; ok {
+		return name, true
+	}
+	lname := strings.ToLower(name)
+	for k := range m {
+		if strings.ToLower(k) == lname {
+			return k, true
+		}
+	}
+	return "", false
+}
+
+// CompareDocument compares 2 documents by sort fields, field prefixed by - is sorted descending
+func CompareDocument(a, b toolkit.M, sorts []string) int {
+	for _, sort := range sorts {
+		desc := strings.HasPrefix(sort, "-")
+		field := strings.TrimPrefix(sort, "-")
+		va, _ := GetPath(a, field)
+		vb, _ := GetPath(b, field)
+		if cmp := dbflex.CompareValue(va, vb, nil); cmp != 0 {
+			if desc {
+				return -cmp
+			}
+			return cmp
+		}
+	}
+	return 0
+}
+
+// Update applies update values and update items on a document, values are copied so the document never
+// shares map or slice with caller. A failed update item leaves the document partially updated
+func Update(m toolkit.M, updates toolkit.M, items []*dbflex.UpdateItem) error {
+	for k, v := range updates {
+		SetPath(m, k, CopyOf(v))
+	}
+	for _, item := range items {
+		if item.Op == dbflex.UpdateUnset {
+			UnsetPath(m, item.Field)
+			continue
+		}
+		current, _ := GetPath(m, item.Field)
+		v, err := item.Apply(current)
+		if err != nil {
+			return err
+		}
+		SetPath(m, item.Field, CopyOf(v))
+	}
+	return nil
+}
+
+// UpdateValues returns fields of data to be updated, limited to update fields if any.
+// Key fields are never updated
+func UpdateValues(data toolkit.M, fields []string, keyFields []string) toolkit.M {
+	updates := toolkit.M{}
+	if data == nil {
+		return updates
+	}
+
+	isKey := func(field string) bool {
+		for _, k := range keyFields {
+			if strings.EqualFold(k, field) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(fields) == 0 {
+		for k, v := range data {
+			if !isKey(k) {
+				updates.Set(k, v)
+			}
+		}
+		return updates
+	}
+
+	for _, field := range fields {
+		if v, ok := GetPath(data, field); ok && !isKey(field) {
+			updates.Set(field, v)
+		}
+	}
+	return updates
+}
+
+// UpdateItems returns update items of a query
+func UpdateItems(q dbflex.IQuery) []*dbflex.UpdateItem {
+	parts := q.Config(dbflex.ConfigKeyGroupedQueryItems, dbflex.GroupedQueryItems{}).(dbflex.GroupedQueryItems)
+	var items []*dbflex.UpdateItem
+	for _, item := range parts[dbflex.QueryModify] {
+		items = append(items, item.Value.([]*dbflex.UpdateItem)...)
+	}
+	return items
+}
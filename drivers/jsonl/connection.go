@@ -0,0 +1,228 @@
+package jsonl
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eaciit/toolkit"
+
+	"github.com/eaciit/dbflex"
+)
+
+func init() {
+	//=== sample: jsonl://localhost/usr/local/data?extension=jsonl
+	dbflex.RegisterDriver("jsonl", func(si *dbflex.ServerInfo) dbflex.IConnection {
+		c := new(Connection)
+		c.ServerInfo = *si
+		c.SetThis(c)
+		c.SetFieldNameTag("json")
+		return c
+	})
+}
+
+// Connection of jsonl driver, each file with jsonl extension on the directory is a table
+// and each line of the file is a json document
+type Connection struct {
+	dbflex.ConnectionBase
+
+	dirInfo   os.FileInfo
+	dirPath   string
+	extension string
+
+	locks      map[string]*sync.RWMutex
+	locksMutex sync.Mutex
+
+	ids      map[string]*idIndex
+	idsMutex sync.Mutex
+}
+
+// idIndex keeps ids of a file, it is valid as long as size and modification time of the file are not changed
+type idIndex struct {
+	size    int64
+	modTime time.Time
+	ids     map[string]bool
+}
+
+func (c *Connection) Connect() error {
+	dirpath := c.Database
+	if dirpath == "" {
+		return toolkit.Errorf("directory is not specified")
+	}
+
+	fi, err := os.Stat(dirpath)
+	if err != nil {
+		return err
+	}
+
+	if fi.IsDir() == false {
+		return toolkit.Errorf("%s is not a directory", dirpath)
+	}
+
+	c.dirInfo = fi
+	c.dirPath = dirpath
+	c.extension = c.Config.Get("extension", "jsonl").(string)
+	return nil
+}
+
+func (c *Connection) State() string {
+	if c.dirInfo != nil {
+		return dbflex.StateConnected
+	}
+	return dbflex.StateUnknown
+}
+
+func (c *Connection) Close() {
+	c.dirInfo = nil
+	c.dirPath = ""
+}
+
+func (c *Connection) NewQuery() dbflex.IQuery {
+	q := new(Query)
+	q.SetThis(q)
+	q.SetConnection(c)
+	return q
+}
+
+func (c *Connection) ObjectNames(dbflex.ObjTypeEnum) []string {
+	files, err := ioutil.ReadDir(c.dirPath)
+	if err != nil {
+		return []string{}
+	}
+
+	names := []string{}
+	for _, fi := range files {
+		name := strings.ToLower(fi.Name())
+		if !fi.IsDir() && strings.HasSuffix(name, "."+c.extension) {
+			names = append(names, name[0:len(name)-len(c.extension)-1])
+		}
+	}
+	return names
+}
+
+func (c *Connection) ValidateTable(interface{}, bool) error {
+	return nil
+}
+
+func (c *Connection) DropTable(name string) error {
+	c.dropIDs(c.tableFilePath(name))
+	return os.Remove(c.tableFilePath(name))
+}
+
+func (c *Connection) tableFilePath(tablename string) string {
+	return filepath.Join(c.dirPath, tablename+"."+c.extension)
+}
+
+// fileMutex returns in-process lock of a file. Reader holds it only while reading,
+// writer holds it while appending or rewriting the file
+func (c *Connection) fileMutex(filePath string) *sync.RWMutex {
+	c.locksMutex.Lock()
+	defer c.locksMutex.Unlock()
+
+	if c.locks == nil {
+		c.locks = map[string]*sync.RWMutex{}
+	}
+	mu, ok := c.locks[filePath]
+	if !ok {
+		mu = new(sync.RWMutex)
+		c.locks[filePath] = mu
+	}
+	return mu
+}
+
+func idKey(id interface{}) string {
+	return toolkit.Sprintf("%v", id)
+}
+
+// hasID checks whether a document with given id is on the file. Ids of the file are read once and kept
+// until the file is changed by others, so inserting n documents does not read the file n times.
+// Caller should hold the file mutex
+func (c *Connection) hasID(filePath string, id interface{}) (bool, error) {
+	fi, err := os.Stat(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	c.idsMutex.Lock()
+	index, ok := c.ids[filePath]
+	c.idsMutex.Unlock()
+	if !ok || index.size != fi.Size() || !index.modTime.Equal(fi.ModTime()) {
+		if index, err = readIDs(filePath); err != nil {
+			return false, err
+		}
+		index.size, index.modTime = fi.Size(), fi.ModTime()
+		c.idsMutex.Lock()
+		if c.ids == nil {
+			c.ids = map[string]*idIndex{}
+		}
+		c.ids[filePath] = index
+		c.idsMutex.Unlock()
+	}
+	return index.ids[idKey(id)], nil
+}
+
+// addID records id of a document appended into the file. Caller should hold the file mutex
+func (c *Connection) addID(filePath string, id interface{}) {
+	c.idsMutex.Lock()
+	defer c.idsMutex.Unlock()
+
+	index, ok := c.ids[filePath]
+	if !ok {
+		return
+	}
+	fi, err := os.Stat(filePath)
+	if err != nil {
+		delete(c.ids, filePath)
+		return
+	}
+	index.ids[idKey(id)] = true
+	index.size, index.modTime = fi.Size(), fi.ModTime()
+}
+
+// dropIDs removes ids of a file after it is rewritten
+func (c *Connection) dropIDs(filePath string) {
+	c.idsMutex.Lock()
+	defer c.idsMutex.Unlock()
+	delete(c.ids, filePath)
+}
+
+func readIDs(filePath string) (*idIndex, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, toolkit.Errorf("unable to open file %s. %s", filePath, err.Error())
+	}
+	defer f.Close()
+
+	index := &idIndex{ids: map[string]bool{}}
+	scanner := newScanner(f)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		doc := map[string]json.RawMessage{}
+		if err = json.Unmarshal(line, &doc); err != nil {
+			return nil, toolkit.Errorf("unable to parse %s. %s", string(line), err.Error())
+		}
+		var id interface{}
+		if raw, ok := doc[IDField]; ok {
+			if err = json.Unmarshal(raw, &id); err != nil {
+				return nil, toolkit.Errorf("unable to parse %s. %s", string(line), err.Error())
+			}
+			index.ids[idKey(id)] = true
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, toolkit.Errorf("unable to read file %s. %s", filePath, err.Error())
+	}
+	return index, nil
+}
@@ -0,0 +1,327 @@
+package jsonl
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"reflect"
+	"sort"
+
+	"github.com/eaciit/toolkit"
+
+	"github.com/eaciit/dbflex"
+	"github.com/eaciit/dbflex/drivers/internal/docutil"
+)
+
+// MaxDocumentSize is maximum size in bytes of a line
+var MaxDocumentSize = 16 * 1024 * 1024
+
+type document struct {
+	raw []byte
+	m   toolkit.M
+}
+
+type Cursor struct {
+	dbflex.CursorBase
+
+	f        *os.File
+	filePath string
+	scanner  *bufio.Scanner
+
+	filter     *dbflex.Filter
+	sorts      []string
+	fields     []string
+	skip, take int
+	aggrs      []*dbflex.AggrItem
+	groups     []string
+
+	docs    []*document
+	loaded  bool
+	skipped int
+	fetched int
+}
+
+func (c *Cursor) Reset() error {
+	c.Close()
+	c.openFile()
+	return c.Error()
+}
+
+func (c *Cursor) Fetch(out interface{}) error {
+	if c.scanner == nil {
+		c.openFile()
+	}
+	if c.Error() != nil {
+		return c.Error()
+	}
+
+	mu := c.Connection().(*Connection).fileMutex(c.filePath)
+	mu.RLock()
+	defer mu.RUnlock()
+
+	doc, ok, err := c.next()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return toolkit.Error("EOF")
+	}
+
+	err = c.decode(doc, out)
+	if c.CloseAfterFetch() {
+		c.Close()
+	}
+	return err
+}
+
+func (c *Cursor) Fetchs(result interface{}, n int) error {
+	if c.scanner == nil {
+		c.openFile()
+	}
+	if c.Error() != nil {
+		return c.Error()
+	}
+
+	mu := c.Connection().(*Connection).fileMutex(c.filePath)
+	mu.RLock()
+	defer mu.RUnlock()
+
+	read := 0
+	v := reflect.TypeOf(result).Elem().Elem()
+	ivs := reflect.MakeSlice(reflect.SliceOf(v), 0, 0)
+	for n == 0 || read < n {
+		doc, ok, err := c.next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+
+		read++
+		ivp := reflect.New(v)
+		if v.Kind() == reflect.Map {
+			ivp.Elem().Set(reflect.MakeMap(v))
+		}
+		if err = c.decode(doc, ivp.Interface()); err != nil {
+			return toolkit.Errorf("unable to serialize data. %s", err.Error())
+		}
+		ivs = reflect.Append(ivs, ivp.Elem())
+	}
+	reflect.ValueOf(result).Elem().Set(ivs)
+
+	if c.CloseAfterFetch() {
+		c.Close()
+	}
+	return nil
+}
+
+// Count returns number of documents that match the filter, or number of groups for aggregation.
+// Skip and take is not applied, file is read using its own handle so it does not affect fetching
+func (c *Cursor) Count() int {
+	mu := c.Connection().(*Connection).fileMutex(c.filePath)
+	mu.RLock()
+	defer mu.RUnlock()
+
+	cc := &Cursor{filePath: c.filePath, filter: c.filter, aggrs: c.aggrs, groups: c.groups}
+	cc.SetThis(cc)
+	cc.SetConnection(c.Connection())
+	cc.openFile()
+	defer cc.Close()
+	if err := cc.Error(); err != nil {
+		if os.IsNotExist(err) {
+			return 0
+		}
+		c.SetError(err)
+		return 0
+	}
+
+	if len(cc.aggrs) > 0 {
+		docs, err := cc.aggregate()
+		if err != nil {
+			c.SetError(err)
+			return 0
+		}
+		return len(docs)
+	}
+
+	n := 0
+	for {
+		doc, err := cc.scanMatch()
+		if err != nil {
+			c.SetError(err)
+			return 0
+		}
+		if doc == nil {
+			return n
+		}
+		n++
+	}
+}
+
+func (c *Cursor) Close() {
+	if c.f != nil {
+		c.f.Close()
+		c.f = nil
+		c.scanner = nil
+	}
+	c.docs = nil
+	c.loaded = false
+}
+
+func (c *Cursor) openFile() {
+	c.SetError(nil)
+	c.skipped = 0
+	c.fetched = 0
+
+	f, err := os.Open(c.filePath)
+	if err != nil {
+		c.SetError(err)
+		return
+	}
+
+	c.f = f
+	c.scanner = newScanner(f)
+}
+
+func newScanner(f *os.File) *bufio.Scanner {
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), MaxDocumentSize)
+	return scanner
+}
+
+// scanMatch returns next document of the file that match the filter
+func (c *Cursor) scanMatch() (*document, error) {
+	for c.scanner.Scan() {
+		line := bytes.TrimSpace(c.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		m := toolkit.M{}
+		if err := json.Unmarshal(line, &m); err != nil {
+			return nil, toolkit.Errorf("unable to parse %s. %s", string(line), err.Error())
+		}
+		match, err := c.filter.Match(m)
+		if err != nil {
+			return nil, err
+		}
+		if match {
+			raw := make([]byte, len(line))
+			copy(raw, line)
+			return &document{raw, m}, nil
+		}
+	}
+	return nil, c.scanner.Err()
+}
+
+// next returns next document after filter, sort, aggregation, skip and take is being applied
+func (c *Cursor) next() (*document, bool, error) {
+	if c.take > 0 && c.fetched >= c.take {
+		return nil, false, nil
+	}
+
+	if (len(c.sorts) > 0 || len(c.aggrs) > 0) && !c.loaded {
+		if err := c.load(); err != nil {
+			return nil, false, err
+		}
+	}
+
+	for {
+		var doc *document
+		if c.loaded {
+			if len(c.docs) == 0 {
+				return nil, false, nil
+			}
+			doc = c.docs[0]
+			c.docs = c.docs[1:]
+		} else {
+			var err error
+			if doc, err = c.scanMatch(); err != nil || doc == nil {
+				return nil, false, err
+			}
+		}
+
+		if c.skipped < c.skip {
+			c.skipped++
+			continue
+		}
+		c.fetched++
+		return doc, true, nil
+	}
+}
+
+// load reads all matched documents, or aggregation result, and sorts them
+func (c *Cursor) load() error {
+	var err error
+	sorts := c.sorts
+	if len(c.aggrs) > 0 {
+		if c.docs, err = c.aggregate(); err != nil {
+			return toolkit.Errorf("unable to aggregate data. %s", err.Error())
+		}
+		sorts = make([]string, len(c.sorts))
+		for idx, s := range c.sorts {
+			sorts[idx] = docutil.GroupKey(s)
+		}
+	} else {
+		c.docs = []*document{}
+		for {
+			doc, err := c.scanMatch()
+			if err != nil {
+				return err
+			}
+			if doc == nil {
+				break
+			}
+			c.docs = append(c.docs, doc)
+		}
+	}
+
+	if len(sorts) > 0 {
+		sort.SliceStable(c.docs, func(i, j int) bool {
+			return docutil.CompareDocument(c.docs[i].m, c.docs[j].m, sorts) < 0
+		})
+	}
+	c.loaded = true
+	return nil
+}
+
+// aggregate computes aggregation per group of matched documents
+func (c *Cursor) aggregate() ([]*document, error) {
+	a := docutil.NewAggregator(c.aggrs, c.groups)
+	for {
+		doc, err := c.scanMatch()
+		if err != nil {
+			return nil, err
+		}
+		if doc == nil {
+			break
+		}
+		a.Add(doc.m)
+	}
+
+	docs := []*document{}
+	for _, m := range a.Result() {
+		docs = append(docs, &document{m: m})
+	}
+	return docs, nil
+}
+
+// decode deserializes a document into out, and keeps only selected fields if any
+func (c *Cursor) decode(doc *document, out interface{}) error {
+	if len(c.fields) == 0 {
+		if doc.raw != nil {
+			return json.Unmarshal(doc.raw, out)
+		}
+		return toolkit.Serde(doc.m, out, "json")
+	}
+
+	m := toolkit.M{}
+	for _, field := range c.fields {
+		if v, ok := docutil.GetPath(doc.m, field); ok {
+			docutil.SetPath(m, field, v)
+		}
+	}
+	return toolkit.Serde(m, out, "json")
+}
@@ -0,0 +1,109 @@
+package jsonl
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eaciit/toolkit"
+
+	"github.com/eaciit/dbflex"
+	"github.com/eaciit/dbflex/testbase"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCRUD(t *testing.T) {
+	workpath, _ := ioutil.TempDir("", "dbflexjsonl")
+	defer os.RemoveAll(workpath)
+
+	crud := testbase.NewCRUD(t, toolkit.Sprintf("jsonl://localhost/%s", workpath), 1000, nil)
+	crud.RunTest()
+}
+
+func TestNestedDocument(t *testing.T) {
+	Convey("Nested document", t, func() {
+		workpath, _ := ioutil.TempDir("", "dbflexjsonl")
+		defer os.RemoveAll(workpath)
+		ioutil.WriteFile(filepath.Join(workpath, "orders.jsonl"), []byte(
+			`{"_id":"O1","customer":{"name":"Ann","city":"Jakarta"},"total":100,"tags":["new","promo"]}`+"\n"+
+				`{"_id":"O2","customer":{"name":"Bob","city":"Bandung"},"total":250,"tags":["old"]}`+"\n"+
+				"\n"+
+				`{"_id":"O3","customer":{"name":"Cid","city":"Jakarta"},"total":50}`+"\n"), 0644)
+
+		conn, err := dbflex.NewConnectionFromUri(toolkit.Sprintf("jsonl://localhost/%s", workpath), nil)
+		So(err, ShouldBeNil)
+		So(conn.Connect(), ShouldBeNil)
+		defer conn.Close()
+		So(conn.ObjectNames(dbflex.ObjTypeTable), ShouldResemble, []string{"orders"})
+
+		Convey("Filter by dot path and array", func() {
+			ms := []toolkit.M{}
+			cur := conn.Cursor(dbflex.From("orders").Select().
+				Where(dbflex.Eq("customer.city", "Jakarta")).OrderBy("-total"), nil)
+			So(cur.Error(), ShouldBeNil)
+			So(cur.Fetchs(&ms, 0), ShouldBeNil)
+			So(len(ms), ShouldEqual, 2)
+			So(ms[0].GetString("_id"), ShouldEqual, "O1")
+			So(cur.Count(), ShouldEqual, 2)
+
+			Convey("Reset fetch from the beginning", func() {
+				So(cur.Reset(), ShouldBeNil)
+				m := toolkit.M{}
+				So(cur.Fetch(&m), ShouldBeNil)
+				So(m.GetString("_id"), ShouldEqual, "O1")
+				cur.Close()
+			})
+
+			cur = conn.Cursor(dbflex.From("orders").Select().Where(dbflex.Eq("tags", "promo")), nil)
+			So(cur.Count(), ShouldEqual, 1)
+			cur.Close()
+		})
+
+		Convey("Modify nested field and save by id", func() {
+			_, err := conn.Execute(dbflex.From("orders").Where(dbflex.Eq("_id", "O2")).
+				Modify(dbflex.Set("customer.city", "Surabaya"), dbflex.Inc("total", 10)), nil)
+			So(err, ShouldBeNil)
+
+			_, err = conn.Execute(dbflex.From("orders").Save(),
+				toolkit.M{}.Set("data", toolkit.M{}.Set("_id", "O3").Set("total", 75)))
+			So(err, ShouldBeNil)
+			_, err = conn.Execute(dbflex.From("orders").Save(),
+				toolkit.M{}.Set("data", toolkit.M{}.Set("_id", "O4").Set("total", 5)))
+			So(err, ShouldBeNil)
+
+			ms := []toolkit.M{}
+			cur := conn.Cursor(dbflex.From("orders").Select().OrderBy("_id"), nil)
+			So(cur.Fetchs(&ms, 0), ShouldBeNil)
+			cur.Close()
+			So(len(ms), ShouldEqual, 4)
+			So(ms[1].Get("customer").(map[string]interface{})["city"], ShouldEqual, "Surabaya")
+			So(ms[1].GetInt("total"), ShouldEqual, 260)
+			So(ms[2].Has("customer"), ShouldBeFalse)
+			So(ms[2].GetInt("total"), ShouldEqual, 75)
+
+			_, err = conn.Execute(dbflex.From("orders").Insert(),
+				toolkit.M{}.Set("data", toolkit.M{}.Set("_id", "O4")))
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Insert rejects duplicate id", func() {
+			insert := func(id string) error {
+				_, err := conn.Execute(dbflex.From("orders").Insert(), toolkit.M{}.Set("data", toolkit.M{}.Set("_id", id)))
+				return err
+			}
+			So(insert("O1"), ShouldNotBeNil)
+			So(insert("O5"), ShouldBeNil)
+			So(insert("O5"), ShouldNotBeNil)
+
+			f, _ := os.OpenFile(filepath.Join(workpath, "orders.jsonl"), os.O_APPEND|os.O_WRONLY, 0644)
+			f.WriteString(`{"_id":"O9"}` + "\n")
+			f.Close()
+			So(insert("O9"), ShouldNotBeNil)
+
+			_, err := conn.Execute(dbflex.From("orders").Delete().Where(dbflex.Eq("_id", "O5")), nil)
+			So(err, ShouldBeNil)
+			So(insert("O5"), ShouldBeNil)
+		})
+	})
+}
@@ -0,0 +1,294 @@
+package jsonl
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eaciit/dbflex"
+	"github.com/eaciit/dbflex/drivers/internal/docutil"
+	"github.com/eaciit/toolkit"
+)
+
+// IDField is name of the key field of a document
+const IDField = "_id"
+
+type Query struct {
+	dbflex.QueryBase
+}
+
+// BuildFilter returns the filter as is, it is evaluated per document by the cursor
+func (q *Query) BuildFilter(f *dbflex.Filter) (interface{}, error) {
+	return f, nil
+}
+
+func (q *Query) BuildCommand() (interface{}, error) {
+	return nil, nil
+}
+
+func (q *Query) filePath() (string, error) {
+	conn := q.Connection().(*Connection)
+	tablename := q.Config(dbflex.ConfigKeyTableName, "").(string)
+
+	if tablename == "" {
+		return "", toolkit.Errorf("no tablename is specified")
+	}
+	return conn.tableFilePath(tablename), nil
+}
+
+func (q *Query) Cursor(toolkit.M) dbflex.ICursor {
+	c := new(Cursor)
+	c.SetThis(c)
+	c.SetConnection(q.Connection())
+
+	filePath, err := q.filePath()
+	if err != nil {
+		c.SetError(err)
+		return c
+	}
+	c.filePath = filePath
+
+	parts := q.Config(dbflex.ConfigKeyGroupedQueryItems, dbflex.GroupedQueryItems{}).(dbflex.GroupedQueryItems)
+	if filter, ok := q.Config(dbflex.ConfigKeyFilter, nil).(*dbflex.Filter); ok {
+		c.filter = filter
+	}
+	c.fields = q.Config("fields", []string{}).([]string)
+	if items, ok := parts[dbflex.QueryOrder]; ok {
+		for _, item := range items {
+			for _, field := range item.Value.([]string) {
+				if strings.TrimSpace(field) != "" {
+					c.sorts = append(c.sorts, strings.TrimSpace(field))
+				}
+			}
+		}
+	}
+	if items, ok := parts[dbflex.QueryAggr]; ok {
+		c.aggrs = items[0].Value.([]*dbflex.AggrItem)
+	}
+	if items, ok := parts[dbflex.QueryGroup]; ok {
+		for _, item := range items {
+			for _, field := range item.Value.([]string) {
+				if strings.TrimSpace(field) != "" {
+					c.groups = append(c.groups, strings.TrimSpace(field))
+				}
+			}
+		}
+	}
+	if items, ok := parts[dbflex.QuerySkip]; ok {
+		c.skip = items[0].Value.(int)
+	}
+	if items, ok := parts[dbflex.QueryTake]; ok {
+		c.take = items[0].Value.(int)
+	}
+
+	c.openFile()
+	return c
+}
+
+func (q *Query) Execute(parm toolkit.M) (interface{}, error) {
+	cmdType := q.Config(dbflex.ConfigKeyCommandType, "").(string)
+	filePath, err := q.filePath()
+	if err != nil {
+		return nil, err
+	}
+
+	if cmdType == dbflex.QuerySelect {
+		return nil, toolkit.Errorf("select command should use cursor instead of execute")
+	}
+
+	mu := q.Connection().(*Connection).fileMutex(filePath)
+	mu.Lock()
+	defer mu.Unlock()
+
+	var data toolkit.M
+	if parm != nil && parm.Has("data") {
+		if data, err = docutil.ToDocument(parm.Get("data")); err != nil {
+			return nil, toolkit.Errorf("unable to serialize data. %s", err.Error())
+		}
+	}
+
+	switch cmdType {
+	case dbflex.QueryInsert:
+		if data == nil {
+			return nil, toolkit.Errorf("insert fail, no data")
+		}
+		if id, ok := data[IDField]; !ok || id == nil || id == "" {
+			data.Set(IDField, toolkit.RandomString(32))
+		}
+
+		conn := q.Connection().(*Connection)
+		exist, err := conn.hasID(filePath, data[IDField])
+		if err != nil {
+			return nil, err
+		}
+		if exist {
+			return nil, toolkit.Errorf("insert fail, duplicate %s %v", IDField, data[IDField])
+		}
+		if err = appendDocument(filePath, data); err != nil {
+			return nil, err
+		}
+		conn.addID(filePath, data[IDField])
+		return data[IDField], nil
+
+	case dbflex.QuerySave:
+		if data == nil {
+			return nil, toolkit.Errorf("save fail, no data")
+		}
+		if id, ok := data[IDField]; !ok || id == nil || id == "" {
+			data.Set(IDField, toolkit.RandomString(32))
+		}
+
+		filter := dbflex.Eq(IDField, data[IDField])
+		affected, err := q.rewrite(filePath, filter, func(m toolkit.M) (toolkit.M, error) {
+			return data, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if affected == 0 {
+			if err = appendDocument(filePath, data); err != nil {
+				return nil, err
+			}
+		}
+		return data[IDField], nil
+
+	case dbflex.QueryUpdate:
+		updateItems := docutil.UpdateItems(q)
+		updates := docutil.UpdateValues(data, q.Config("fields", []string{}).([]string), []string{IDField})
+		if len(updates) == 0 && len(updateItems) == 0 {
+			return nil, toolkit.Errorf("update need to have data or update items")
+		}
+
+		filter, _ := q.Config(dbflex.ConfigKeyFilter, nil).(*dbflex.Filter)
+		return q.rewrite(filePath, filter, func(m toolkit.M) (toolkit.M, error) {
+			if err := docutil.Update(m, updates, updateItems); err != nil {
+				return nil, err
+			}
+			return m, nil
+		})
+
+	case dbflex.QueryDelete:
+		filter, _ := q.Config(dbflex.ConfigKeyFilter, nil).(*dbflex.Filter)
+		return q.rewrite(filePath, filter, func(toolkit.M) (toolkit.M, error) {
+			return nil, nil
+		})
+	}
+
+	return nil, toolkit.Errorf("unknown command: %s", cmdType)
+}
+
+// rewrite streams the file into a temp file on the same directory, each document that match the filter
+// is replaced by result of fn or removed if fn returns nil. The temp file then replaces the original file.
+// It returns number of affected documents
+func (q *Query) rewrite(filePath string, filter *dbflex.Filter, fn func(toolkit.M) (toolkit.M, error)) (int, error) {
+	stat, err := os.Stat(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, toolkit.Errorf("unable to open file %s. %s", filePath, err.Error())
+	}
+
+	src, err := os.Open(filePath)
+	if err != nil {
+		return 0, toolkit.Errorf("unable to open file %s. %s", filePath, err.Error())
+	}
+	defer src.Close()
+
+	tempFile, err := ioutil.TempFile(filepath.Dir(filePath), filepath.Base(filePath)+"_temp_")
+	if err != nil {
+		return 0, toolkit.Errorf("unable to create temp file. %s", err.Error())
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			tempFile.Close()
+			os.Remove(tempFile.Name())
+		}
+	}()
+
+	w := bufio.NewWriter(tempFile)
+	scanner := newScanner(src)
+	affected := 0
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		m := toolkit.M{}
+		if err = json.Unmarshal(line, &m); err != nil {
+			return 0, toolkit.Errorf("unable to parse %s. %s", string(line), err.Error())
+		}
+		match, err := filter.Match(m)
+		if err != nil {
+			return 0, err
+		}
+
+		if match {
+			affected++
+			if m, err = fn(m); err != nil {
+				return 0, err
+			}
+			if m == nil {
+				continue
+			}
+			if line, err = json.Marshal(m); err != nil {
+				return 0, toolkit.Errorf("unable to serialize data. %s", err.Error())
+			}
+		}
+
+		w.Write(line)
+		if err = w.WriteByte('\n'); err != nil {
+			return 0, toolkit.Errorf("unable to write temp file. %s", err.Error())
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return 0, toolkit.Errorf("unable to read file %s. %s", filePath, err.Error())
+	}
+
+	if err = w.Flush(); err != nil {
+		return 0, toolkit.Errorf("unable to write temp file. %s", err.Error())
+	}
+	if err = tempFile.Sync(); err != nil {
+		return 0, toolkit.Errorf("unable to write temp file. %s", err.Error())
+	}
+	if err = tempFile.Close(); err != nil {
+		return 0, toolkit.Errorf("unable to write temp file. %s", err.Error())
+	}
+	os.Chmod(tempFile.Name(), stat.Mode())
+	src.Close()
+
+	if err = os.Rename(tempFile.Name(), filePath); err != nil {
+		return 0, toolkit.Errorf("unable to replace file %s. %s", filePath, err.Error())
+	}
+	committed = true
+	q.Connection().(*Connection).dropIDs(filePath)
+	return affected, nil
+}
+
+// appendDocument writes a document as a new line at the end of the file, the file is created if not exist
+func appendDocument(filePath string, m toolkit.M) error {
+	bs, err := json.Marshal(m)
+	if err != nil {
+		return toolkit.Errorf("unable to serialize data. %s", err.Error())
+	}
+
+	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return toolkit.Errorf("unable to open file %s. %s", filePath, err.Error())
+	}
+	defer file.Close()
+
+	if _, err = file.Write(append(bs, '\n')); err != nil {
+		return toolkit.Errorf("unable to write to file %s. %s", filePath, err.Error())
+	}
+	if err = file.Sync(); err != nil {
+		return toolkit.Errorf("unable to write to file %s. %s", filePath, err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package memory
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/eaciit/toolkit"
+
+	"github.com/eaciit/dbflex"
+	"github.com/eaciit/dbflex/drivers/internal/docutil"
+)
+
+func init() {
+	//=== sample: memory://localhost/testdb
+	dbflex.RegisterDriver("memory", func(si *dbflex.ServerInfo) dbflex.IConnection {
+		c := new(Connection)
+		c.ServerInfo = *si
+		c.SetThis(c)
+		c.SetFieldNameTag("json")
+		return c
+	})
+}
+
+// DefaultDatabase is name of the database used when it is not specified on the connection
+const DefaultDatabase = "default"
+
+// database keeps tables of a memory database. It is shared by all connections with the same database name
+type database struct {
+	sync.RWMutex
+	tables map[string][]toolkit.M
+}
+
+var (
+	databases      = map[string]*database{}
+	databasesMutex sync.Mutex
+)
+
+func getDatabase(name string) *database {
+	databasesMutex.Lock()
+	defer databasesMutex.Unlock()
+
+	db, ok := databases[name]
+	if !ok {
+		db = &database{tables: map[string][]toolkit.M{}}
+		databases[name] = db
+	}
+	return db
+}
+
+// Snapshot is a copy of all tables of a memory database
+type Snapshot map[string][]toolkit.M
+
+// Connection of memory driver, tables are kept in-process as collections of toolkit.M
+// and live as long as the process
+type Connection struct {
+	dbflex.ConnectionBase
+
+	db *database
+}
+
+func (c *Connection) Connect() error {
+	name := c.Database
+	if name == "" {
+		name = DefaultDatabase
+	}
+	c.db = getDatabase(name)
+	return nil
+}
+
+func (c *Connection) State() string {
+	if c.db != nil {
+		return dbflex.StateConnected
+	}
+	return dbflex.StateUnknown
+}
+
+func (c *Connection) Close() {
+	c.db = nil
+}
+
+func (c *Connection) NewQuery() dbflex.IQuery {
+	q := new(Query)
+	q.SetThis(q)
+	q.SetConnection(c)
+	return q
+}
+
+func (c *Connection) ObjectNames(dbflex.ObjTypeEnum) []string {
+	names := []string{}
+	if c.db == nil {
+		return names
+	}
+
+	c.db.RLock()
+	defer c.db.RUnlock()
+	for name := range c.db.tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (c *Connection) ValidateTable(interface{}, bool) error {
+	return nil
+}
+
+func (c *Connection) DropTable(name string) error {
+	if c.db == nil {
+		return toolkit.Errorf("connection is not yet established")
+	}
+
+	c.db.Lock()
+	defer c.db.Unlock()
+	delete(c.db.tables, name)
+	return nil
+}
+
+// Snapshot returns a copy of current state of the database, it could be restored later using Restore
+func (c *Connection) Snapshot() Snapshot {
+	s := Snapshot{}
+	if c.db == nil {
+		return s
+	}
+
+	c.db.RLock()
+	defer c.db.RUnlock()
+	for name, docs := range c.db.tables {
+		s[name] = docutil.CopyDocuments(docs)
+	}
+	return s
+}
+
+// Restore replaces all tables of the database with the snapshot
+func (c *Connection) Restore(s Snapshot) error {
+	if c.db == nil {
+		return toolkit.Errorf("connection is not yet established")
+	}
+
+	c.db.Lock()
+	defer c.db.Unlock()
+	c.db.tables = map[string][]toolkit.M{}
+	for name, docs := range s {
+		c.db.tables[name] = docutil.CopyDocuments(docs)
+	}
+	return nil
+}
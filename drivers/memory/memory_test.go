@@ -0,0 +1,119 @@
+package memory
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eaciit/toolkit"
+
+	"github.com/eaciit/dbflex"
+	"github.com/eaciit/dbflex/testbase"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCRUD(t *testing.T) {
+	crud := testbase.NewCRUD(t, "memory://localhost/crud", 1000, nil)
+	crud.RunTest()
+}
+
+type item struct {
+	ID      string `json:"_id"`
+	Name    string
+	Qty     int
+	Created time.Time
+	Tags    []string `json:",omitempty"`
+}
+
+func TestCommands(t *testing.T) {
+	Convey("Memory commands", t, func() {
+		conn, err := dbflex.NewConnectionFromUri("memory://localhost/commands", nil)
+		So(err, ShouldBeNil)
+		So(conn.Connect(), ShouldBeNil)
+		defer conn.Close()
+		defer conn.DropTable("items")
+
+		now := time.Now()
+		for i := 1; i <= 5; i++ {
+			_, err = conn.Execute(dbflex.From("items").Insert(), toolkit.M{}.Set("data",
+				&item{toolkit.Sprintf("I%d", i), toolkit.Sprintf("Item %d", i), i * 10, now.AddDate(0, 0, -i), nil}))
+			So(err, ShouldBeNil)
+		}
+		_, err = conn.Execute(dbflex.From("items").Insert(), toolkit.M{}.Set("data", &item{ID: "I1"}))
+		So(err, ShouldNotBeNil)
+
+		Convey("Values keep their type", func() {
+			ms := []toolkit.M{}
+			cur := conn.Cursor(dbflex.From("items").Select().Where(dbflex.Lt("created", now.AddDate(0, 0, -3))), nil)
+			So(cur.Fetchs(&ms, 0), ShouldBeNil)
+			So(len(ms), ShouldEqual, 2)
+			So(ms[0].Get("Qty"), ShouldEqual, 40)
+			So(ms[0].Get("Created"), ShouldHaveSameTypeAs, time.Time{})
+		})
+
+		Convey("Order, skip, take and count", func() {
+			items := []item{}
+			cur := conn.Cursor(dbflex.From("items").Select().OrderBy("-qty").Skip(1).Take(2), nil)
+			So(cur.Fetchs(&items, 0), ShouldBeNil)
+			So(len(items), ShouldEqual, 2)
+			So(items[0].ID, ShouldEqual, "I4")
+			So(items[1].ID, ShouldEqual, "I3")
+			So(cur.Count(), ShouldEqual, 5)
+
+			So(cur.Reset(), ShouldBeNil)
+			it := new(item)
+			So(cur.Fetch(it), ShouldBeNil)
+			So(it.ID, ShouldEqual, "I4")
+		})
+
+		Convey("Update, save and delete", func() {
+			n, err := conn.Execute(dbflex.From("items").Where(dbflex.Gte("qty", 40)).
+				Modify(dbflex.Inc("qty", 1), dbflex.Push("Tags", "big")), nil)
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 2)
+
+			_, err = conn.Execute(dbflex.From("items").Save(), toolkit.M{}.Set("data", &item{ID: "I6", Qty: 1}))
+			So(err, ShouldBeNil)
+
+			n, err = conn.Execute(dbflex.From("items").Where(dbflex.Lt("qty", 20)).Delete(), nil)
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 2)
+
+			ms := []toolkit.M{}
+			So(conn.Cursor(dbflex.From("items").Select().OrderBy("_id"), nil).Fetchs(&ms, 0), ShouldBeNil)
+			So(len(ms), ShouldEqual, 4)
+			So(ms[3].Get("Qty"), ShouldEqual, 51)
+			So(ms[3].Get("Tags"), ShouldResemble, []interface{}{"big"})
+		})
+
+		Convey("Snapshot and restore", func() {
+			mconn := conn.(*Connection)
+			snapshot := mconn.Snapshot()
+			_, err := conn.Execute(dbflex.From("items").Delete(), nil)
+			So(err, ShouldBeNil)
+			So(conn.Cursor(dbflex.From("items").Select(), nil).Count(), ShouldEqual, 0)
+
+			So(mconn.Restore(snapshot), ShouldBeNil)
+			So(conn.Cursor(dbflex.From("items").Select(), nil).Count(), ShouldEqual, 5)
+		})
+
+		Convey("Concurrent use", func() {
+			wg := new(sync.WaitGroup)
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					conn.Execute(dbflex.From("items").Insert(), toolkit.M{}.Set("data", &item{Qty: i}))
+					conn.Execute(dbflex.From("items").Where(dbflex.Eq("_id", "I1")).Modify(dbflex.Inc("qty", 1)), nil)
+					conn.Cursor(dbflex.From("items").Select(), nil).Count()
+				}(i)
+			}
+			wg.Wait()
+
+			it := new(item)
+			So(conn.Cursor(dbflex.From("items").Select().Where(dbflex.Eq("_id", "I1")), nil).Fetch(it), ShouldBeNil)
+			So(it.Qty, ShouldEqual, 30)
+			So(conn.Cursor(dbflex.From("items").Select(), nil).Count(), ShouldEqual, 25)
+		})
+	})
+}
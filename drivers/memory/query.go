@@ -0,0 +1,172 @@
+package memory
+
+import (
+	"github.com/eaciit/dbflex"
+	"github.com/eaciit/dbflex/drivers/internal/docutil"
+	"github.com/eaciit/toolkit"
+)
+
+// IDField is name of the key field of a document
+const IDField = "_id"
+
+type Query struct {
+	dbflex.QueryBase
+}
+
+// BuildFilter returns the filter as is, it is evaluated per document by the cursor
+func (q *Query) BuildFilter(f *dbflex.Filter) (interface{}, error) {
+	return f, nil
+}
+
+func (q *Query) BuildCommand() (interface{}, error) {
+	return nil, nil
+}
+
+func (q *Query) database() (*database, string, error) {
+	db := q.Connection().(*Connection).db
+	if db == nil {
+		return nil, "", toolkit.Errorf("connection is not yet established")
+	}
+
+	tablename := q.Config(dbflex.ConfigKeyTableName, "").(string)
+	if tablename == "" {
+		return nil, "", toolkit.Errorf("no tablename is specified")
+	}
+	return db, tablename, nil
+}
+
+func (q *Query) Cursor(toolkit.M) dbflex.ICursor {
+	c := docutil.NewCursor(q)
+	db, tablename, err := q.database()
+	if err != nil {
+		c.SetError(err)
+		return c
+	}
+
+	return c.Load(func(filter *dbflex.Filter) ([]toolkit.M, error) {
+		db.RLock()
+		defer db.RUnlock()
+
+		matched := []toolkit.M{}
+		for _, doc := range db.tables[tablename] {
+			match, err := filter.Match(doc)
+			if err != nil {
+				return nil, err
+			}
+			if match {
+				matched = append(matched, doc)
+			}
+		}
+		return docutil.CopyDocuments(matched), nil
+	})
+}
+
+func (q *Query) Execute(parm toolkit.M) (interface{}, error) {
+	cmdType := q.Config(dbflex.ConfigKeyCommandType, "").(string)
+	db, tablename, err := q.database()
+	if err != nil {
+		return nil, err
+	}
+
+	if cmdType == dbflex.QuerySelect {
+		return nil, toolkit.Errorf("select command should use cursor instead of execute")
+	}
+
+	var data toolkit.M
+	if parm != nil && parm.Has("data") {
+		if data, err = docutil.ToDocument(parm.Get("data")); err != nil {
+			return nil, toolkit.Errorf("unable to serialize data. %s", err.Error())
+		}
+	}
+
+	db.Lock()
+	defer db.Unlock()
+	docs := db.tables[tablename]
+
+	switch cmdType {
+	case dbflex.QueryInsert:
+		if data == nil {
+			return nil, toolkit.Errorf("insert fail, no data")
+		}
+		if id, ok := data[IDField]; !ok || id == nil || id == "" {
+			data.Set(IDField, toolkit.RandomString(32))
+		}
+		if indexOf(docs, data[IDField]) >= 0 {
+			return nil, toolkit.Errorf("insert fail, duplicate %s %v", IDField, data[IDField])
+		}
+		db.tables[tablename] = append(docs, data)
+		return data[IDField], nil
+
+	case dbflex.QuerySave:
+		if data == nil {
+			return nil, toolkit.Errorf("save fail, no data")
+		}
+		if id, ok := data[IDField]; !ok || id == nil || id == "" {
+			data.Set(IDField, toolkit.RandomString(32))
+		}
+		if idx := indexOf(docs, data[IDField]); idx >= 0 {
+			docs[idx] = data
+		} else {
+			db.tables[tablename] = append(docs, data)
+		}
+		return data[IDField], nil
+
+	case dbflex.QueryUpdate:
+		updateItems := docutil.UpdateItems(q)
+		updates := docutil.UpdateValues(data, q.Config("fields", []string{}).([]string), []string{IDField})
+		if len(updates) == 0 && len(updateItems) == 0 {
+			return nil, toolkit.Errorf("update need to have data or update items")
+		}
+
+		//-- changes are applied to copies, so a failed update leaves the table untouched
+		filter, _ := q.Config(dbflex.ConfigKeyFilter, nil).(*dbflex.Filter)
+		changed := map[int]toolkit.M{}
+		for idx, doc := range docs {
+			match, err := filter.Match(doc)
+			if err != nil {
+				return nil, err
+			}
+			if !match {
+				continue
+			}
+
+			m := docutil.CopyOf(doc).(toolkit.M)
+			if err = docutil.Update(m, updates, updateItems); err != nil {
+				return nil, err
+			}
+			changed[idx] = m
+		}
+		for idx, m := range changed {
+			docs[idx] = m
+		}
+		return len(changed), nil
+
+	case dbflex.QueryDelete:
+		filter, _ := q.Config(dbflex.ConfigKeyFilter, nil).(*dbflex.Filter)
+		kept := make([]toolkit.M, 0, len(docs))
+		for _, doc := range docs {
+			match, err := filter.Match(doc)
+			if err != nil {
+				return nil, err
+			}
+			if !match {
+				kept = append(kept, doc)
+			}
+		}
+		if _, exist := db.tables[tablename]; exist {
+			db.tables[tablename] = kept
+		}
+		return len(docs) - len(kept), nil
+	}
+
+	return nil, toolkit.Errorf("unknown command: %s", cmdType)
+}
+
+func indexOf(docs []toolkit.M, id interface{}) int {
+	for idx, doc := range docs {
+		if dbflex.CompareValue(doc[IDField], id, nil) == 0 {
+			return idx
+		}
+	}
+	return -1
+}
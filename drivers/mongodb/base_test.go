@@ -3,10 +3,142 @@ package mongodb
 import (
 	"testing"
 
+	"github.com/eaciit/dbflex"
 	"github.com/eaciit/dbflex/testbase"
+	"github.com/eaciit/toolkit"
+	. "github.com/smartystreets/goconvey/convey"
 )
 
 func TestCRUD(t *testing.T) {
 	crud := testbase.NewCRUD(t, "mongodb://localhost:27123/dbtest", 1000, nil)
 	crud.RunTest()
 }
+
+func TestCommand(t *testing.T) {
+	Convey("Run mongodb command", t, func() {
+		conn, err := dbflex.NewConnectionFromUri("mongodb://localhost:27123/dbtest", nil)
+		So(err, ShouldBeNil)
+		So(conn.Connect(), ShouldBeNil)
+		defer conn.Close()
+
+		Convey("Distinct", func() {
+			res, err := conn.Execute(dbflex.From("employees").Command(CommandDistinct, "grade"), nil)
+			So(err, ShouldBeNil)
+			So(len(res.([]interface{})), ShouldBeGreaterThan, 0)
+		})
+
+		Convey("Aggregate with raw pipeline", func() {
+			pipe := []toolkit.M{
+				toolkit.M{}.Set("$group", toolkit.M{}.Set("_id", "").Set("count", toolkit.M{}.Set("$sum", 1))),
+			}
+			res := []toolkit.M{}
+			err := conn.Cursor(dbflex.From("employees").Command(CommandAggregate, pipe), nil).Fetchs(&res, 0)
+			So(err, ShouldBeNil)
+			So(len(res), ShouldEqual, 1)
+		})
+
+		Convey("Run command", func() {
+			res, err := conn.Execute(new(dbflex.CommandBase).Command(CommandRun, toolkit.M{}.Set("ping", 1)), nil)
+			So(err, ShouldBeNil)
+			So(res.(toolkit.M).GetInt("ok"), ShouldEqual, 1)
+		})
+	})
+}
+
+func TestCommandOptions(t *testing.T) {
+	Convey("Read command options", t, func() {
+		data := toolkit.M{}.Set("upsert", true).Set("new", "true").Set("remove", float64(0)).
+			Set("Unique", 1).Set("sparse", "yes").Set("expireAfterSeconds", 60)
+
+		for name, expected := range map[string]bool{"upsert": true, "new": true, "remove": false, "unique": true, "background": false} {
+			b, err := commandBool(data, name)
+			So(err, ShouldBeNil)
+			So(b, ShouldEqual, expected)
+		}
+		_, err := commandBool(data, "sparse")
+		So(err, ShouldNotBeNil)
+		_, err = commandBool(toolkit.M{}.Set("upsert", 2), "upsert")
+		So(err, ShouldNotBeNil)
+
+		v, ok := commandOption(data, "expireafterseconds")
+		So(ok, ShouldBeTrue)
+		So(v, ShouldEqual, 60)
+	})
+}
+
+func TestAggrSortTake(t *testing.T) {
+	Convey("Aggregate with sort and take", t, func() {
+		conn, err := dbflex.NewConnectionFromUri("mongodb://localhost:27123/dbtest", nil)
+		So(err, ShouldBeNil)
+		So(conn.Connect(), ShouldBeNil)
+		defer conn.Close()
+
+		res := []struct {
+			Grade  int
+			Salary int
+		}{}
+		cursor := conn.Cursor(dbflex.From("employees").GroupBy("grade").
+			Aggr(dbflex.Sum("salary")).OrderBy("-salary").Take(3), nil)
+		So(cursor.Error(), ShouldBeNil)
+		So(cursor.Count(), ShouldEqual, 3)
+
+		err = cursor.Fetchs(&res, 0)
+		So(err, ShouldBeNil)
+		So(len(res), ShouldEqual, 3)
+		So(res[0].Grade, ShouldBeGreaterThan, 0)
+		So(res[0].Salary, ShouldBeGreaterThanOrEqualTo, res[1].Salary)
+	})
+}
+
+func TestModify(t *testing.T) {
+	Convey("Update with increment and push", t, func() {
+		conn, err := dbflex.NewConnectionFromUri("mongodb://localhost:27123/dbtest", nil)
+		So(err, ShouldBeNil)
+		So(conn.Connect(), ShouldBeNil)
+		defer conn.Close()
+
+		_, err = conn.Execute(dbflex.From("employees").Where(dbflex.Eq("_id", "EMP-00011")).
+			Modify(dbflex.Inc("salary", 100), dbflex.Push("tags", "raised"), dbflex.CurrentDate("lastupdate")), nil)
+		So(err, ShouldBeNil)
+	})
+}
+
+func TestIndex(t *testing.T) {
+	Convey("Manage index", t, func() {
+		conn, err := dbflex.NewConnectionFromUri("mongodb://localhost:27123/dbtest", nil)
+		So(err, ShouldBeNil)
+		So(conn.Connect(), ShouldBeNil)
+		defer conn.Close()
+
+		err = conn.EnsureIndex("employees", dbflex.NewIndex("grade_salary", "grade", "-salary"))
+		So(err, ShouldBeNil)
+
+		indexes, err := conn.Indexes("employees")
+		So(err, ShouldBeNil)
+		found := false
+		for _, index := range indexes {
+			if index.Name == "grade_salary" {
+				found = true
+				So(index.Fields, ShouldResemble, []string{"grade", "-salary"})
+			}
+		}
+		So(found, ShouldBeTrue)
+
+		So(conn.DropIndex("employees", "grade_salary"), ShouldBeNil)
+	})
+}
+
+func TestDescribe(t *testing.T) {
+	Convey("Describe collection", t, func() {
+		conn, err := dbflex.NewConnectionFromUri("mongodb://localhost:27123/dbtest", nil)
+		So(err, ShouldBeNil)
+		So(conn.Connect(), ShouldBeNil)
+		defer conn.Close()
+
+		schema, err := conn.Describe("employees")
+		So(err, ShouldBeNil)
+		So(schema.Field("_id").Key, ShouldBeTrue)
+		So(schema.Field("grade").GoType, ShouldEqual, "int")
+		So(schema.Field("joindate").GoType, ShouldEqual, "time.Time")
+	})
+}
@@ -0,0 +1,235 @@
+package mongodb
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	df "github.com/eaciit/dbflex"
+	. "github.com/eaciit/toolkit"
+	mgo "gopkg.in/mgo.v2"
+)
+
+// Command names that can be passed to dbflex.ICommand.Command.
+// Command name is case insensitive
+const (
+	CommandDistinct      = "distinct"
+	CommandFindAndModify = "findandmodify"
+	CommandCreateIndexes = "createindexes"
+	CommandAggregate     = "aggregate"
+	CommandRun           = "runcommand"
+)
+
+func (q *Query) commandItem() (string, interface{}, error) {
+	parts := q.Config(df.ConfigKeyGroupedQueryItems, df.GroupedQueryItems{}).(df.GroupedQueryItems)
+	items, ok := parts[df.QueryCommand]
+	if !ok || len(items) == 0 {
+		return "", nil, Error("no command is defined")
+	}
+
+	cmdM, ok := items[0].Value.(M)
+	if !ok {
+		return "", nil, Errorf("invalid command. %v", items[0].Value)
+	}
+	return strings.ToLower(cmdM.GetString("command")), cmdM.Get("data"), nil
+}
+
+func (q *Query) collection() (*mgo.Collection, error) {
+	tablename := q.Config(df.ConfigKeyTableName, "").(string)
+	if tablename == "" {
+		return nil, Error("command need a table, use From to define it")
+	}
+	return q.db.C(tablename), nil
+}
+
+// executeCommand runs named mongodb command. distinct returns []interface{}, findAndModify
+// and runCommand return toolkit.M, aggregate returns dbflex.ICursor
+func (q *Query) executeCommand(m M) (interface{}, error) {
+	name, data, err := q.commandItem()
+	if err != nil {
+		return nil, err
+	}
+	where := q.Config(df.ConfigKeyWhere, M{}).(M)
+
+	switch name {
+	case CommandDistinct:
+		coll, err := q.collection()
+		if err != nil {
+			return nil, err
+		}
+		field := ""
+		if dataM, ok := data.(M); ok {
+			field = dataM.GetString("field")
+		} else {
+			field = ToString(data)
+		}
+		if field == "" {
+			return nil, Error("distinct need a field")
+		}
+		result := []interface{}{}
+		if err = coll.Find(where).Distinct(field, &result); err != nil {
+			return nil, Errorf("unable to run distinct. %s", err.Error())
+		}
+		return result, nil
+
+	case CommandFindAndModify:
+		coll, err := q.collection()
+		if err != nil {
+			return nil, err
+		}
+		dataM, err := ToM(data)
+		if err != nil {
+			return nil, Errorf("unable to deserialize command data: %s", err.Error())
+		}
+		change := mgo.Change{Update: dataM.Get("update")}
+		if change.Upsert, err = commandBool(dataM, "upsert"); err != nil {
+			return nil, err
+		}
+		if change.Remove, err = commandBool(dataM, "remove"); err != nil {
+			return nil, err
+		}
+		if change.ReturnNew, err = commandBool(dataM, "new"); err != nil {
+			return nil, err
+		}
+		qry := coll.Find(where)
+		if sorts := toStrings(dataM.Get("sort")); len(sorts) > 0 {
+			qry = qry.Sort(sorts...)
+		}
+		result := M{}
+		if _, err = qry.Apply(change, &result); err != nil {
+			return nil, Errorf("unable to run findAndModify. %s", err.Error())
+		}
+		return result, nil
+
+	case CommandCreateIndexes:
+		coll, err := q.collection()
+		if err != nil {
+			return nil, err
+		}
+		dataM, err := ToM(data)
+		if err != nil {
+			return nil, Errorf("unable to deserialize command data: %s", err.Error())
+		}
+		index := mgo.Index{
+			Key:  toStrings(dataM.Get("key")),
+			Name: dataM.GetString("name"),
+		}
+		if index.Unique, err = commandBool(dataM, "unique"); err != nil {
+			return nil, err
+		}
+		if index.Sparse, err = commandBool(dataM, "sparse"); err != nil {
+			return nil, err
+		}
+		if index.Background, err = commandBool(dataM, "background"); err != nil {
+			return nil, err
+		}
+		if v, ok := commandOption(dataM, "expireAfterSeconds"); ok && v != nil {
+			ttl, isNumber := df.NumberValue(v)
+			if !isNumber || ttl < 0 {
+				return nil, Errorf("expireAfterSeconds should be a positive number, got %v", v)
+			}
+			index.ExpireAfter = time.Duration(ttl) * time.Second
+		}
+		if len(index.Key) == 0 {
+			return nil, Error("createIndexes need a key")
+		}
+		return nil, coll.EnsureIndex(index)
+
+	case CommandAggregate:
+		cursor := q.This().Cursor(m)
+		return cursor, cursor.Error()
+
+	case CommandRun:
+		if data == nil {
+			return nil, Error("runCommand need a command document")
+		}
+		result := M{}
+		if err = q.db.Run(data, &result); err != nil {
+			return nil, Errorf("unable to run command. %s", err.Error())
+		}
+		return result, nil
+	}
+
+	return nil, Errorf("command %s is not supported", name)
+}
+
+// commandCursor returns pipe cursor for aggregate command, data should be the raw pipeline
+func (q *Query) commandCursor(cursor *Cursor) {
+	name, data, err := q.commandItem()
+	if err != nil {
+		cursor.SetError(err)
+		return
+	}
+
+	if name != CommandAggregate {
+		cursor.SetError(Errorf("command %s does not return cursor", name))
+		return
+	}
+
+	coll, err := q.collection()
+	if err != nil {
+		cursor.SetError(err)
+		return
+	}
+
+	pipe := coll.Pipe(data).AllowDiskUse()
+	cursor.isPipe = true
+	cursor.mgocoll = coll
+	if rv := reflect.ValueOf(data); rv.Kind() == reflect.Slice {
+		for i := 0; i < rv.Len(); i++ {
+			cursor.pipeline = append(cursor.pipeline, rv.Index(i).Interface())
+		}
+	}
+	cursor.mgopipe = pipe
+	cursor.mgoiter = pipe.Iter()
+}
+
+// commandOption returns an option of command data, option name is case insensitive
+func commandOption(m M, name string) (interface{}, bool) {
+	if v, ok := m[name]; ok {
+		return v, true
+	}
+	for k, v := range m {
+		if strings.EqualFold(k, name) {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// commandBool reads a bool option, it could be a bool, a text of bool, or number 0 or 1. Missing option is false
+func commandBool(m M, name string) (bool, error) {
+	v, ok := commandOption(m, name)
+	if !ok || v == nil {
+		return false, nil
+	}
+
+	if b, isBool := v.(bool); isBool {
+		return b, nil
+	}
+	if s, isText := v.(string); isText {
+		if b, err := strconv.ParseBool(strings.TrimSpace(s)); err == nil {
+			return b, nil
+		}
+	} else if n, isNumber := df.NumberValue(v); isNumber && (n == 0 || n == 1) {
+		return n == 1, nil
+	}
+	return false, Errorf("%s should be a bool, got %v", name, v)
+}
+
+func toStrings(v interface{}) []string {
+	switch vs := v.(type) {
+	case []string:
+		return vs
+	case string:
+		return []string{vs}
+	case []interface{}:
+		out := []string{}
+		for _, s := range vs {
+			out = append(out, ToString(s))
+		}
+		return out
+	}
+	return []string{}
+}
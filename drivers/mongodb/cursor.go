@@ -13,12 +13,14 @@ type Cursor struct {
 	mgocursor *mgo.Query
 	mgoiter   *mgo.Iter
 	mgopipe   *mgo.Pipe
+	mgocoll   *mgo.Collection
+	pipeline  []interface{}
 
 	isPipe bool
 }
 
 func (c *Cursor) Reset() error {
-	if c.mgocursor == nil {
+	if c.mgocursor == nil && c.mgopipe == nil {
 		return toolkit.Error("Cursor is not properly initialized")
 	}
 	if c.mgoiter != nil {
@@ -48,6 +50,10 @@ func (c *Cursor) Fetch(result interface{}) error {
 }
 
 func (c *Cursor) Count() int {
+	if c.isPipe {
+		return c.pipeCount()
+	}
+
 	if c.mgocursor == nil {
 		return 0
 	}
@@ -55,6 +61,29 @@ func (c *Cursor) Count() int {
 	return n
 }
 
+// pipeCount counts pipe result by appending count stage into the cursor pipeline
+func (c *Cursor) pipeCount() int {
+	if c.mgocoll == nil {
+		c.SetError(toolkit.Error("Cursor is not properly initialized"))
+		return 0
+	}
+
+	pipes := append([]interface{}{}, c.pipeline...)
+	pipes = append(pipes, toolkit.M{}.Set("$group", toolkit.M{}.
+		Set("_id", "").
+		Set("count", toolkit.M{}.Set("$sum", 1))))
+
+	res := toolkit.M{}
+	if err := c.mgocoll.Pipe(pipes).AllowDiskUse().One(&res); err != nil {
+		if err == mgo.ErrNotFound {
+			return 0
+		}
+		c.SetError(toolkit.Errorf("unable to get count. %s", err.Error()))
+		return 0
+	}
+	return res.GetInt("count")
+}
+
 func (c *Cursor) Fetchs(result interface{}, n int) error {
 	defer func() {
 		if c.CloseAfterFetch() {
@@ -0,0 +1,70 @@
+package mongodb
+
+import (
+	"strings"
+
+	"github.com/eaciit/dbflex"
+	"github.com/eaciit/toolkit"
+	mgo "gopkg.in/mgo.v2"
+)
+
+func (c *Connection) EnsureIndex(tablename string, index *dbflex.Index) error {
+	if c.mgosession == nil {
+		return toolkit.Error("no valid connection")
+	}
+	if len(index.Fields) == 0 {
+		return toolkit.Errorf("index %s has no field", index.Name)
+	}
+
+	mgoIndex := mgo.Index{
+		Name:        index.Name,
+		Unique:      index.Unique,
+		ExpireAfter: index.TTL,
+	}
+	for _, field := range index.Fields {
+		if index.Text {
+			field = "$text:" + strings.TrimPrefix(field, "-")
+		}
+		mgoIndex.Key = append(mgoIndex.Key, field)
+	}
+
+	if err := c.mgosession.DB(c.Database).C(tablename).EnsureIndex(mgoIndex); err != nil {
+		return toolkit.Errorf("unable to create index %s. %s", index.Name, err.Error())
+	}
+	return nil
+}
+
+func (c *Connection) DropIndex(tablename, indexname string) error {
+	if c.mgosession == nil {
+		return toolkit.Error("no valid connection")
+	}
+	if err := c.mgosession.DB(c.Database).C(tablename).DropIndexName(indexname); err != nil {
+		return toolkit.Errorf("unable to drop index %s. %s", indexname, err.Error())
+	}
+	return nil
+}
+
+func (c *Connection) Indexes(tablename string) ([]*dbflex.Index, error) {
+	if c.mgosession == nil {
+		return nil, toolkit.Error("no valid connection")
+	}
+
+	mgoIndexes, err := c.mgosession.DB(c.Database).C(tablename).Indexes()
+	if err != nil {
+		return nil, toolkit.Errorf("unable to get indexes. %s", err.Error())
+	}
+
+	indexes := []*dbflex.Index{}
+	for _, mgoIndex := range mgoIndexes {
+		index := dbflex.NewIndex(mgoIndex.Name).SetUnique(mgoIndex.Unique).SetTTL(mgoIndex.ExpireAfter)
+		for _, key := range mgoIndex.Key {
+			if strings.HasPrefix(key, "$text:") {
+				index.Text = true
+				key = key[len("$text:"):]
+			}
+			index.Fields = append(index.Fields, key)
+		}
+		indexes = append(indexes, index)
+	}
+	return indexes, nil
+}
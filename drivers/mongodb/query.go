@@ -8,6 +8,7 @@ import (
 
 	"github.com/eaciit/toolkit"
 	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
 
 	df "github.com/eaciit/dbflex"
 	. "github.com/eaciit/toolkit"
@@ -93,6 +94,11 @@ func (q *Query) Cursor(m M) df.ICursor {
 	cursor := new(Cursor)
 	cursor.SetThis(cursor)
 
+	if q.Config(df.ConfigKeyCommandType, "") == df.QueryCommand {
+		q.commandCursor(cursor)
+		return cursor
+	}
+
 	tablename := q.Config(df.ConfigKeyTableName, "").(string)
 	coll := q.db.C(tablename)
 
@@ -114,6 +120,7 @@ func (q *Query) Cursor(m M) df.ICursor {
 				aggrExpression.Set(item.Alias, M{}.Set(string(item.Op), "$"+item.Field))
 			}
 		}
+		groupKeys := []string{}
 		if !hasGroup {
 			aggrExpression.Set("_id", "")
 		} else {
@@ -123,7 +130,9 @@ func (q *Query) Cursor(m M) df.ICursor {
 					gs := v.Value.([]string)
 					for _, g := range gs {
 						if strings.TrimSpace(g) != "" {
-							s.Set(strings.Replace(g, ".", "_", -1), "$"+g)
+							key := strings.Replace(g, ".", "_", -1)
+							s.Set(key, "$"+g)
+							groupKeys = append(groupKeys, key)
 						}
 					}
 				}
@@ -136,8 +145,54 @@ func (q *Query) Cursor(m M) df.ICursor {
 			pipes = append(pipes, M{}.Set("$match", where))
 		}
 		pipes = append(pipes, M{}.Set("$group", aggrExpression))
+
+		//-- flatten group keys so result can be sorted and fetched by its field name
+		if len(groupKeys) > 0 {
+			projection := M{}.Set("_id", 1)
+			for _, item := range items {
+				projection.Set(item.Alias, 1)
+			}
+			for _, key := range groupKeys {
+				projection.Set(key, "$_id."+key)
+			}
+			pipes = append(pipes, M{}.Set("$project", projection))
+		}
+
+		if orderItems, ok := parts[df.QueryOrder]; ok {
+			sorts := bson.D{}
+			for _, orderItem := range orderItems {
+				for _, field := range orderItem.Value.([]string) {
+					field = strings.TrimSpace(field)
+					if field == "" {
+						continue
+					}
+					if strings.HasPrefix(field, "-") {
+						sorts = append(sorts, bson.DocElem{Name: strings.Replace(field[1:], ".", "_", -1), Value: -1})
+					} else {
+						sorts = append(sorts, bson.DocElem{Name: strings.Replace(field, ".", "_", -1), Value: 1})
+					}
+				}
+			}
+			if len(sorts) > 0 {
+				pipes = append(pipes, M{}.Set("$sort", sorts))
+			}
+		}
+
+		if items, ok := parts[df.QuerySkip]; ok {
+			pipes = append(pipes, M{}.Set("$skip", items[0].Value.(int)))
+		}
+
+		if items, ok := parts[df.QueryTake]; ok {
+			pipes = append(pipes, M{}.Set("$limit", items[0].Value.(int)))
+		}
+
 		pipe := coll.Pipe(pipes).AllowDiskUse()
 		cursor.isPipe = true
+		cursor.mgocoll = coll
+		cursor.pipeline = make([]interface{}, len(pipes))
+		for idx, p := range pipes {
+			cursor.pipeline[idx] = p
+		}
 		cursor.mgopipe = pipe
 		cursor.mgoiter = pipe.Iter()
 	} else {
@@ -187,30 +242,50 @@ func (q *Query) Execute(m M) (interface{}, error) {
 			singleupdate := false
 			if !singleupdate {
 				//-- get the field for update
-				updateqi, _ := parts[df.QueryUpdate]
-				updatevals := updateqi[0].Value.([]string)
+				updatevals := []string{}
+				if updateqi, ok := parts[df.QueryUpdate]; ok {
+					updatevals = updateqi[0].Value.([]string)
+				}
 
-				var dataM toolkit.M
-				dataM, err = toolkit.ToM(data)
 				dataS := toolkit.M{}
-				if err != nil {
-					return nil, err
-				}
+				if data != nil {
+					var dataM toolkit.M
+					dataM, err = toolkit.ToM(data)
+					if err != nil {
+						return nil, err
+					}
 
-				if len(updatevals) > 0 {
-					for k, v := range dataM {
-						for _, u := range updatevals {
-							if strings.ToLower(k) == strings.ToLower(u) {
-								dataS[k] = v
+					if len(updatevals) > 0 {
+						for k, v := range dataM {
+							for _, u := range updatevals {
+								if strings.ToLower(k) == strings.ToLower(u) {
+									dataS[k] = v
+								}
 							}
 						}
+					} else {
+						for k, v := range dataM {
+							dataS[strings.ToLower(k)] = v
+						}
 					}
-				} else {
-					for k, v := range dataM {
-						dataS[strings.ToLower(k)] = v
+				}
+
+				updatedData := toolkit.M{}
+				if len(dataS) > 0 {
+					updatedData.Set("$set", dataS)
+				}
+				if modifyqi, ok := parts[df.QueryModify]; ok {
+					for _, qi := range modifyqi {
+						for _, item := range qi.Value.([]*df.UpdateItem) {
+							if err = buildUpdateItem(updatedData, item); err != nil {
+								return nil, err
+							}
+						}
 					}
 				}
-				updatedData := toolkit.M{}.Set("$set", dataS)
+				if len(updatedData) == 0 {
+					return nil, toolkit.Errorf("update need to have data or update items")
+				}
 
 				_, err = coll.UpdateAll(where, updatedData)
 			} else {
@@ -240,7 +315,51 @@ func (q *Query) Execute(m M) (interface{}, error) {
 		}
 		_, err = coll.Upsert(whereSave, data)
 		return nil, err
+
+	case df.QueryCommand:
+		return q.executeCommand(m)
 	}
 
 	return nil, nil
 }
+
+func buildUpdateItem(update M, item *df.UpdateItem) error {
+	op := string(item.Op)
+	var value interface{}
+	switch item.Op {
+	case df.UpdateSet, df.UpdateInc, df.UpdateMul:
+		value = item.Value
+
+	case df.UpdateCurrentDate:
+		value = true
+
+	case df.UpdateUnset:
+		value = ""
+
+	case df.UpdatePush:
+		values := item.Value.([]interface{})
+		if len(values) == 1 {
+			value = values[0]
+		} else {
+			value = M{}.Set("$each", values)
+		}
+
+	case df.UpdatePull:
+		values := item.Value.([]interface{})
+		if len(values) == 1 {
+			value = values[0]
+		} else {
+			value = M{}.Set("$in", values)
+		}
+
+	default:
+		return fmt.Errorf("Update Op %s is not defined", item.Op)
+	}
+
+	opM, ok := update.Get(op, M{}).(M)
+	if !ok {
+		opM = M{}
+	}
+	update.Set(op, opM.Set(item.Field, value))
+	return nil
+}
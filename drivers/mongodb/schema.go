@@ -0,0 +1,98 @@
+package mongodb
+
+import (
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/eaciit/dbflex"
+	"github.com/eaciit/toolkit"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// DescribeSampleSize is number of documents being sampled to infer field types
+var DescribeSampleSize = 100
+
+// Describe infers schema of a collection by sampling its documents
+func (c *Connection) Describe(tablename string) (*dbflex.TableSchema, error) {
+	if c.mgosession == nil {
+		return nil, toolkit.Error("no valid connection")
+	}
+
+	iter := c.mgosession.DB(c.Database).C(tablename).Find(nil).Limit(DescribeSampleSize).Iter()
+	fields := map[string]*dbflex.FieldSchema{}
+	names := []string{}
+	docCount := 0
+	fieldCount := map[string]int{}
+
+	doc := bson.M{}
+	for iter.Next(&doc) {
+		docCount++
+		for name, v := range doc {
+			nativeType, goType := bsonType(v)
+			field, ok := fields[name]
+			if !ok {
+				field = &dbflex.FieldSchema{Name: name, NativeType: nativeType, GoType: goType, Key: name == "_id"}
+				fields[name] = field
+				names = append(names, name)
+			}
+			if v == nil {
+				field.Nullable = true
+			} else if field.NativeType == "null" {
+				field.NativeType, field.GoType = nativeType, goType
+			} else if field.NativeType != nativeType {
+				field.NativeType, field.GoType = "mixed", "interface {}"
+			}
+			fieldCount[name]++
+		}
+		doc = bson.M{}
+	}
+	if err := iter.Close(); err != nil {
+		return nil, toolkit.Errorf("unable to describe %s. %s", tablename, err.Error())
+	}
+
+	sort.Strings(names)
+	schema := &dbflex.TableSchema{Name: tablename}
+	for _, name := range names {
+		field := fields[name]
+		if fieldCount[name] < docCount {
+			field.Nullable = true
+		}
+		schema.Fields = append(schema.Fields, field)
+	}
+
+	if docCount > 0 {
+		indexes, err := c.Indexes(tablename)
+		if err != nil {
+			return nil, err
+		}
+		schema.SetIndexes(indexes)
+	}
+	return schema, nil
+}
+
+func bsonType(v interface{}) (string, string) {
+	switch v.(type) {
+	case nil:
+		return "null", "interface {}"
+	case string:
+		return "string", "string"
+	case int:
+		return "int", "int"
+	case int64:
+		return "long", "int64"
+	case float64:
+		return "double", "float64"
+	case bool:
+		return "bool", "bool"
+	case time.Time:
+		return "date", "time.Time"
+	case bson.ObjectId:
+		return "objectId", "bson.ObjectId"
+	case bson.M:
+		return "object", "toolkit.M"
+	case []interface{}:
+		return "array", "[]interface {}"
+	}
+	return reflect.TypeOf(v).String(), reflect.TypeOf(v).String()
+}
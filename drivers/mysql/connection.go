@@ -65,6 +65,6 @@ func (c *Connection) Close() {
 func (c *Connection) NewQuery() dbflex.IQuery {
 	q := new(Query)
 	q.SetThis(q)
-	q.db = c.db
+	q.SetDB(c.db).SetDialect(new(Dialect))
 	return q
 }
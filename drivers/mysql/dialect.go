@@ -0,0 +1,69 @@
+package mysql
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/eaciit/dbflex/drivers/rdbms"
+)
+
+// Dialect of mysql, identifiers are quoted using backtick and string literal uses backslash escape
+type Dialect struct {
+	rdbms.BaseDialect
+}
+
+func (d *Dialect) Quote(name string) string {
+	return rdbms.QuoteParts(name, "`", "`")
+}
+
+// Paging uses maximum number of rows as limit when only skip is set, as mysql has no OFFSET without LIMIT
+func (d *Dialect) Paging(cmdtxt string, take, skip int) string {
+	if take <= 0 && skip > 0 {
+		return cmdtxt + " LIMIT 18446744073709551615 OFFSET " + strconv.Itoa(skip)
+	}
+	return d.BaseDialect.Paging(cmdtxt, take, skip)
+}
+
+func (d *Dialect) BoolLiteral(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+func (d *Dialect) StringLiteral(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", "''").Replace(s) + "'"
+}
+
+// Like uses default escape character of mysql, which is backslash
+func (d *Dialect) Like(field, pattern string) string {
+	return field + " LIKE " + pattern
+}
+
+func (d *Dialect) Upsert(insert string, columns, keys []string) string {
+	updates := []string{}
+	for _, column := range columns {
+		if !hasString(keys, column) {
+			updates = append(updates, column+" = VALUES("+column+")")
+		}
+	}
+	if len(updates) == 0 {
+		updates = append(updates, keys[0]+" = "+keys[0])
+	}
+	return insert + " ON DUPLICATE KEY UPDATE " + strings.Join(updates, ", ")
+}
+
+// SerialKey is false, the key value is inserted as is. An auto increment column generates
+// the key on zero value unless NO_AUTO_VALUE_ON_ZERO is set
+func (d *Dialect) SerialKey(interface{}) bool {
+	return false
+}
+
+func hasString(values []string, find string) bool {
+	for _, v := range values {
+		if v == find {
+			return true
+		}
+	}
+	return false
+}
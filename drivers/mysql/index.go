@@ -0,0 +1,125 @@
+package mysql
+
+import (
+	"strings"
+
+	"github.com/eaciit/dbflex"
+	"github.com/eaciit/toolkit"
+)
+
+func (c *Connection) EnsureIndex(tablename string, index *dbflex.Index) error {
+	if c.db == nil {
+		return toolkit.Error("no valid connection")
+	}
+	if len(index.Fields) == 0 {
+		return toolkit.Errorf("index %s has no field", index.Name)
+	}
+	if index.TTL > 0 {
+		return toolkit.Errorf("TTL index is not supported by mysql")
+	}
+
+	indexes, err := c.Indexes(tablename)
+	if err != nil {
+		return err
+	}
+	for _, existing := range indexes {
+		if !strings.EqualFold(existing.Name, index.Name) {
+			continue
+		}
+		if sameIndex(existing, index) {
+			return nil
+		}
+		//-- definition is changed, index is recreated
+		if err = c.DropIndex(tablename, existing.Name); err != nil {
+			return err
+		}
+		break
+	}
+
+	d := new(Dialect)
+	fields := []string{}
+	for _, field := range index.Fields {
+		if strings.HasPrefix(field, "-") && !index.Text {
+			fields = append(fields, d.Quote(field[1:])+" DESC")
+		} else {
+			fields = append(fields, d.Quote(strings.TrimPrefix(field, "-")))
+		}
+	}
+
+	kind := ""
+	if index.Text {
+		kind = "FULLTEXT "
+	} else if index.Unique {
+		kind = "UNIQUE "
+	}
+	cmdtxt := toolkit.Sprintf("CREATE %sINDEX %s ON %s (%s)",
+		kind, d.Quote(index.Name), d.Quote(tablename), strings.Join(fields, ","))
+	if _, err = c.db.Exec(cmdtxt); err != nil {
+		return toolkit.Errorf("%s. SQL Command: %s", err.Error(), cmdtxt)
+	}
+	return nil
+}
+
+// sameIndex checks whether existing index has the definition of index. Descending order of full text field is ignored
+func sameIndex(existing, index *dbflex.Index) bool {
+	if existing.Unique != index.Unique || existing.Text != index.Text || len(existing.Fields) != len(index.Fields) {
+		return false
+	}
+	for idx, field := range index.Fields {
+		if index.Text {
+			field = strings.TrimPrefix(field, "-")
+		}
+		if !strings.EqualFold(existing.Fields[idx], field) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Connection) DropIndex(tablename, indexname string) error {
+	if c.db == nil {
+		return toolkit.Error("no valid connection")
+	}
+	d := new(Dialect)
+	cmdtxt := toolkit.Sprintf("DROP INDEX %s ON %s", d.Quote(indexname), d.Quote(tablename))
+	if _, err := c.db.Exec(cmdtxt); err != nil {
+		return toolkit.Errorf("%s. SQL Command: %s", err.Error(), cmdtxt)
+	}
+	return nil
+}
+
+func (c *Connection) Indexes(tablename string) ([]*dbflex.Index, error) {
+	if c.db == nil {
+		return nil, toolkit.Error("no valid connection")
+	}
+
+	rows, err := c.db.Query("SELECT INDEX_NAME, COLUMN_NAME, NON_UNIQUE, IFNULL(COLLATION,''), INDEX_TYPE "+
+		"FROM information_schema.statistics WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? "+
+		"ORDER BY INDEX_NAME, SEQ_IN_INDEX", c.Database, tablename)
+	if err != nil {
+		return nil, toolkit.Errorf("unable to get indexes. %s", err.Error())
+	}
+	defer rows.Close()
+
+	indexes := []*dbflex.Index{}
+	var index *dbflex.Index
+	for rows.Next() {
+		var (
+			name, column, collation, indexType string
+			nonUnique                          int
+		)
+		if err = rows.Scan(&name, &column, &nonUnique, &collation, &indexType); err != nil {
+			return nil, toolkit.Errorf("unable to get indexes. %s", err.Error())
+		}
+
+		if index == nil || index.Name != name {
+			index = dbflex.NewIndex(name).SetUnique(nonUnique == 0).SetText(indexType == "FULLTEXT")
+			indexes = append(indexes, index)
+		}
+		if collation == "D" {
+			column = "-" + column
+		}
+		index.Fields = append(index.Fields, column)
+	}
+	return indexes, rows.Err()
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/eaciit/dbflex"
 	"github.com/eaciit/dbflex/testbase"
+	. "github.com/smartystreets/goconvey/convey"
 )
 
 const (
@@ -16,3 +17,73 @@ func TestCRUD(t *testing.T) {
 	crud.Set("deletefilter", dbflex.Eq("id", "EMP-10"))
 	crud.RunTest()
 }
+
+func TestModify(t *testing.T) {
+	Convey("Update with increment", t, func() {
+		conn, err := dbflex.NewConnectionFromUri(sqlconnectionstring, nil)
+		So(err, ShouldBeNil)
+		So(conn.Connect(), ShouldBeNil)
+		defer conn.Close()
+
+		_, err = conn.Execute(dbflex.From("employees").Where(dbflex.Eq("id", "EMP-00011")).
+			Modify(dbflex.Inc("salary", 100), dbflex.Set("note", "raised")), nil)
+		So(err, ShouldBeNil)
+	})
+}
+
+func TestIndex(t *testing.T) {
+	Convey("Manage index", t, func() {
+		conn, err := dbflex.NewConnectionFromUri(sqlconnectionstring, nil)
+		So(err, ShouldBeNil)
+		So(conn.Connect(), ShouldBeNil)
+		defer conn.Close()
+
+		err = conn.EnsureIndex("employees", dbflex.NewIndex("idx_grade_salary", "grade", "-salary"))
+		So(err, ShouldBeNil)
+
+		indexes, err := conn.Indexes("employees")
+		So(err, ShouldBeNil)
+		found := false
+		for _, index := range indexes {
+			if index.Name == "idx_grade_salary" {
+				found = true
+				So(len(index.Fields), ShouldEqual, 2)
+			}
+		}
+		So(found, ShouldBeTrue)
+
+		err = conn.EnsureIndex("employees", dbflex.NewIndex("idx_grade_salary", "grade").SetUnique(true))
+		So(err, ShouldBeNil)
+		indexes, _ = conn.Indexes("employees")
+		for _, index := range indexes {
+			if index.Name == "idx_grade_salary" {
+				So(index.Unique, ShouldBeTrue)
+				So(index.Fields, ShouldResemble, []string{"grade"})
+			}
+		}
+
+		So(conn.DropIndex("employees", "idx_grade_salary"), ShouldBeNil)
+	})
+
+	Convey("Compare index definition", t, func() {
+		existing := dbflex.NewIndex("idx_grade", "grade", "-salary")
+		So(sameIndex(existing, dbflex.NewIndex("IDX_GRADE", "Grade", "-salary")), ShouldBeTrue)
+		So(sameIndex(existing, dbflex.NewIndex("idx_grade", "grade", "salary")), ShouldBeFalse)
+		So(sameIndex(existing, dbflex.NewIndex("idx_grade", "grade", "-salary").SetUnique(true)), ShouldBeFalse)
+		So(sameIndex(existing, dbflex.NewIndex("idx_grade", "grade")), ShouldBeFalse)
+	})
+}
+
+func TestDescribe(t *testing.T) {
+	Convey("Describe table", t, func() {
+		conn, err := dbflex.NewConnectionFromUri(sqlconnectionstring, nil)
+		So(err, ShouldBeNil)
+		So(conn.Connect(), ShouldBeNil)
+		defer conn.Close()
+
+		schema, err := conn.Describe("employees")
+		So(err, ShouldBeNil)
+		So(schema.Field("id"), ShouldNotBeNil)
+		So(schema.Field("id").Key, ShouldBeTrue)
+	})
+}
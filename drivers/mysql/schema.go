@@ -0,0 +1,82 @@
+package mysql
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/eaciit/dbflex"
+	"github.com/eaciit/toolkit"
+)
+
+// Describe returns schema of a table based on information_schema.columns
+func (c *Connection) Describe(tablename string) (*dbflex.TableSchema, error) {
+	if c.db == nil {
+		return nil, toolkit.Error("no valid connection")
+	}
+
+	rows, err := c.db.Query("SELECT COLUMN_NAME, DATA_TYPE, COLUMN_TYPE, IS_NULLABLE, COLUMN_DEFAULT, COLUMN_KEY "+
+		"FROM information_schema.columns WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? "+
+		"ORDER BY ORDINAL_POSITION", c.Database, tablename)
+	if err != nil {
+		return nil, toolkit.Errorf("unable to describe %s. %s", tablename, err.Error())
+	}
+	defer rows.Close()
+
+	schema := &dbflex.TableSchema{Name: tablename}
+	for rows.Next() {
+		var (
+			name, dataType, columnType, nullable, key string
+			def                                       sql.NullString
+		)
+		if err = rows.Scan(&name, &dataType, &columnType, &nullable, &def, &key); err != nil {
+			return nil, toolkit.Errorf("unable to describe %s. %s", tablename, err.Error())
+		}
+
+		field := &dbflex.FieldSchema{
+			Name:       name,
+			NativeType: columnType,
+			GoType:     goType(dataType, columnType),
+			Nullable:   nullable == "YES",
+			Key:        key == "PRI",
+		}
+		if def.Valid {
+			field.Default = def.String
+		}
+		schema.Fields = append(schema.Fields, field)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, toolkit.Errorf("unable to describe %s. %s", tablename, err.Error())
+	}
+	if len(schema.Fields) == 0 {
+		return nil, toolkit.Errorf("table %s could not be found", tablename)
+	}
+
+	indexes, err := c.Indexes(tablename)
+	if err != nil {
+		return nil, err
+	}
+	schema.SetIndexes(indexes)
+	return schema, nil
+}
+
+func goType(dataType, columnType string) string {
+	switch strings.ToLower(dataType) {
+	case "tinyint":
+		if strings.ToLower(columnType) == "tinyint(1)" {
+			return "bool"
+		}
+		return "int"
+	case "smallint", "mediumint", "int", "integer", "bigint", "year":
+		return "int"
+	case "decimal", "numeric", "float", "double", "real":
+		return "float64"
+	case "date", "datetime", "timestamp":
+		return "time.Time"
+	case "bit":
+		return "bool"
+	case "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob":
+		return "[]uint8"
+	default:
+		return "string"
+	}
+}
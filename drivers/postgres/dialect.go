@@ -0,0 +1,32 @@
+package postgres
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/eaciit/dbflex/drivers/rdbms"
+)
+
+// Dialect of postgres, arguments are written as $n and inserted keys are returned using RETURNING clause
+type Dialect struct {
+	rdbms.BaseDialect
+}
+
+func (d *Dialect) Placeholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+// Like is case insensitive to follow regex based filter of mongodb driver,
+// backslash is the default escape character of postgres
+func (d *Dialect) Like(field, pattern string) string {
+	return field + " ILIKE " + pattern
+}
+
+func (d *Dialect) Returning(keys []string) string {
+	return "RETURNING " + strings.Join(keys, ", ")
+}
+
+// ColumnName is lower case, as postgres folds unquoted column names of a table definition
+func (d *Dialect) ColumnName(field string) string {
+	return strings.ToLower(field)
+}
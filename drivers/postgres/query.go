@@ -0,0 +1,19 @@
+package postgres
+
+import (
+	"github.com/eaciit/dbflex"
+	"github.com/eaciit/dbflex/drivers/rdbms"
+	"github.com/eaciit/toolkit"
+)
+
+// Query implementaion of dbflex.IQuery, command is generated by rdbms.Query using Dialect
+type Query struct {
+	rdbms.Query
+}
+
+// Cursor produces a cursor from query
+func (q *Query) Cursor(in toolkit.M) dbflex.ICursor {
+	cursor := new(Cursor)
+	cursor.SetThis(cursor)
+	return q.OpenCursor(&cursor.Cursor, in)
+}
@@ -4,7 +4,9 @@ import (
 	"database/sql"
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/eaciit/toolkit"
 
@@ -83,8 +85,46 @@ func (c *Cursor) Scan() error {
 	return c.fetcher.Scan(c.valuesPtr...)
 }
 
+// SerializeFieldType converts a column value into dtype. The value is int64, float64, bool, string, []byte
+// or time.Time as scanned by database/sql, nil is converted into zero value. A driver overrides it
+// when its database returns other types
 func (c *Cursor) SerializeFieldType(name string, dtype reflect.Type, value interface{}) (interface{}, error) {
-	return nil, toolkit.Error("SerializeFieldType is not yet properlhy implemented")
+	switch dtype.String() {
+	case "time.Time":
+		switch v := value.(type) {
+		case time.Time:
+			return v, nil
+		case nil:
+			return time.Time{}, nil
+		}
+		return toolkit.ToDate(toolkit.ToString(value), "yyyy-MM-dd hh:mm:ss"), nil
+	case "int", "int32", "int64":
+		if value == nil {
+			return int(0), nil
+		}
+		v, e := strconv.Atoi(toolkit.ToString(value))
+		if e != nil {
+			return int(0), toolkit.Errorf("%s=%v can't be serialised to int", name, value)
+		}
+		return v, nil
+	case "float", "float32", "float64":
+		if value == nil {
+			return float64(0), nil
+		}
+		val, e := strconv.ParseFloat(toolkit.ToString(value), 64)
+		if e != nil {
+			return float64(0), toolkit.Errorf("%s=%v can't be serialised to float", name, value)
+		}
+		return val, nil
+	case "bool":
+		valstr := toolkit.ToString(value)
+		return (valstr == "1" || valstr == "true"), nil
+	default:
+		if value == nil {
+			return "", nil
+		}
+		return toolkit.ToString(value), nil
+	}
 }
 
 func (c *Cursor) serializeField(name string, value interface{}) (interface{}, error) {
@@ -113,8 +153,7 @@ func (c *Cursor) serialize(dest interface{}) error {
 
 	for k, v := range c.m {
 		var vtr interface{}
-		if vtr, err = c.serializeField(k,
-			string(c.values[toolkit.ToInt(v, toolkit.RoundingAuto)].([]byte))); err != nil {
+		if vtr, err = c.serializeField(k, c.value(toolkit.ToInt(v, toolkit.RoundingAuto))); err != nil {
 			return err
 		} else {
 			mobj.Set(k, vtr)
@@ -172,7 +211,8 @@ func (c *Cursor) Fetchs(obj interface{}, n int) error {
 	for loop {
 		err = c.Scan()
 		if err != nil {
-			if n == 0 && err.Error() == "EOF" {
+			// rows left are less than n, they are returned as the last batch
+			if err.Error() == "EOF" {
 				loop = false
 				err = nil
 			} else {
@@ -215,17 +255,18 @@ func (c *Cursor) getTypeList(obj interface{}) {
 	//-- obj is not accepted, retrieve via fetcher
 	if len(fieldnames) == 0 {
 		for k, v := range c.m {
-			vindex := v.(int)
-			vbytes := c.values[vindex].([]byte)
-			vtype := c.getValueType(vbytes)
+			vtype := c.getValueType(c.value(v.(int)))
 			c.dataTypeList.Set(k, vtype)
 		}
 	}
 }
 
-func (c *Cursor) getValueType(bs []byte) reflect.Type {
+func (c *Cursor) getValueType(v interface{}) reflect.Type {
 	var vtype reflect.Type
-	value := string(bs)
+	value, isString := v.(string)
+	if !isString && v != nil {
+		return reflect.TypeOf(v)
+	}
 
 	if _, e := toolkit.IsStringNumber(value, ""); e == nil {
 		vtype = reflect.TypeOf(float64(0))
@@ -234,3 +275,12 @@ func (c *Cursor) getValueType(bs []byte) reflect.Type {
 	}
 	return vtype
 }
+
+// value returns scanned value of a column. Text returned as []byte by the driver is converted into string,
+// other value such as int64, float64 or time.Time is returned as is
+func (c *Cursor) value(idx int) interface{} {
+	if bs, ok := c.values[idx].([]byte); ok {
+		return string(bs)
+	}
+	return c.values[idx]
+}
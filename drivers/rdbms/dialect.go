@@ -0,0 +1,151 @@
+package rdbms
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eaciit/toolkit"
+)
+
+// Dialect is SQL syntax of a database. Statement of Query is generated using the dialect set by the driver
+type Dialect interface {
+	// Quote returns quoted identifier, name could be dotted like schema.table
+	Quote(name string) string
+	// Placeholder returns placeholder of n-th argument, n starts from 1
+	Placeholder(n int) string
+	// Paging applies take and skip into a select command, zero means it is not set
+	Paging(cmdtxt string, take, skip int) string
+
+	// BoolLiteral, DateFormat and StringLiteral are used by Literal to write a value into the command
+	BoolLiteral(b bool) string
+	DateFormat() string
+	StringLiteral(s string) string
+
+	// Like returns expression that matches field against a pattern, wildcards of the pattern value
+	// are escaped using LikeEscape
+	Like(field, pattern string) string
+	LikeEscape() string
+
+	// Upsert appends into an insert command a clause to update non key columns on conflict of keys
+	Upsert(insert string, columns, keys []string) string
+	// Returning returns clause of insert to return value of keys, empty if it is not supported
+	Returning(keys []string) string
+	// SerialKey returns true if a key column with the value is left out of insert command,
+	// so the key is generated by the database
+	SerialKey(value interface{}) bool
+
+	// ColumnName returns column of a struct field that has no sqlname tag
+	ColumnName(field string) string
+}
+
+// BaseDialect is ANSI flavored dialect, a driver dialect embeds it and overrides what is different
+type BaseDialect struct {
+}
+
+func (d *BaseDialect) Quote(name string) string {
+	return QuoteParts(name, `"`, `"`)
+}
+
+func (d *BaseDialect) Placeholder(int) string {
+	return "?"
+}
+
+func (d *BaseDialect) Paging(cmdtxt string, take, skip int) string {
+	if take > 0 {
+		cmdtxt += " LIMIT " + strconv.Itoa(take)
+	}
+	if skip > 0 {
+		cmdtxt += " OFFSET " + strconv.Itoa(skip)
+	}
+	return cmdtxt
+}
+
+func (d *BaseDialect) BoolLiteral(b bool) string {
+	if b {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+func (d *BaseDialect) DateFormat() string {
+	return "yyyy-MM-dd hh:mm:ss"
+}
+
+func (d *BaseDialect) StringLiteral(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+func (d *BaseDialect) Like(field, pattern string) string {
+	return field + " LIKE " + pattern + " ESCAPE '\\'"
+}
+
+func (d *BaseDialect) LikeEscape() string {
+	return `\`
+}
+
+func (d *BaseDialect) Upsert(insert string, columns, keys []string) string {
+	updates := []string{}
+	for _, column := range columns {
+		if !hasString(keys, column) {
+			updates = append(updates, column+" = EXCLUDED."+column)
+		}
+	}
+	insert += " ON CONFLICT (" + strings.Join(keys, ", ") + ")"
+	if len(updates) == 0 {
+		return insert + " DO NOTHING"
+	}
+	return insert + " DO UPDATE SET " + strings.Join(updates, ", ")
+}
+
+func (d *BaseDialect) Returning([]string) string {
+	return ""
+}
+
+// SerialKey returns true for zero of an integer
+func (d *BaseDialect) SerialKey(value interface{}) bool {
+	return isSerial(value)
+}
+
+// ColumnName returns the field name as is
+func (d *BaseDialect) ColumnName(field string) string {
+	return field
+}
+
+// QuoteParts quotes each part of a dotted name using open and close character,
+// close character inside a name is doubled
+func QuoteParts(name, openSign, closeSign string) string {
+	parts := strings.Split(name, ".")
+	for idx, part := range parts {
+		if part != "*" {
+			parts[idx] = openSign + strings.Replace(part, closeSign, closeSign+closeSign, -1) + closeSign
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// Literal writes a value as literal of the dialect
+func Literal(d Dialect, v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return d.StringLiteral(v)
+	case bool:
+		return d.BoolLiteral(v)
+	case time.Time:
+		return d.StringLiteral(toolkit.Date2String(v, d.DateFormat()))
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return toolkit.Sprintf("%d", v)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	vstr := toolkit.Sprintf("%v", v)
+	if _, err := strconv.ParseFloat(vstr, 64); err == nil {
+		return vstr
+	}
+	return d.StringLiteral(vstr)
+}
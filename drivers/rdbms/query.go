@@ -1,356 +1,391 @@
 package rdbms
 
 import (
-	"bytes"
+	"database/sql"
 	"reflect"
-	"strconv"
 	"strings"
-	"time"
-
-	"text/template"
 
 	"github.com/eaciit/dbflex"
 	"github.com/eaciit/toolkit"
 )
 
+// Query generates SQL command using dialect of the driver. Values are never written into the command,
+// they are passed to the database as arguments
 type Query struct {
 	dbflex.QueryBase
+	db      *sql.DB
+	dialect Dialect
+}
+
+// SetDB sets database used to execute the command
+func (q *Query) SetDB(db *sql.DB) *Query {
+	q.db = db
+	return q
+}
+
+// DB returns database used to execute the command
+func (q *Query) DB() *sql.DB {
+	return q.db
+}
+
+// SetDialect sets dialect used to generate the command
+func (q *Query) SetDialect(d Dialect) *Query {
+	q.dialect = d
+	return q
 }
 
-func (q *Query) Templates() map[string]string {
-	return map[string]string{
-		string(dbflex.QuerySelect): "SELECT {{.FIELDS}} FROM {{." + dbflex.ConfigKeyTableName + "}} " +
-			"{{." + dbflex.QueryWhere + "}} " +
-			"{{." + dbflex.QueryOrder + "}} " +
-			"{{." + dbflex.QueryGroup + "}} " +
-			"{{." + dbflex.QueryTake + "}} " +
-			"{{." + dbflex.QuerySkip + "}}",
-		//dbflex.QueryWhere: "{{." + dbflex.QueryWhere + "}}",
-		dbflex.QueryTake:  "LIMIT {{." + dbflex.QueryTake + "}}",
-		dbflex.QuerySkip:  "OFFSET {{." + dbflex.QuerySkip + "}}",
-		dbflex.QueryGroup: "{{." + dbflex.QueryGroup + "}}",
-		dbflex.QueryOrder: "ORDER BY {{." + dbflex.QueryOrder + "}}",
-		dbflex.QueryInsert: "INSERT INTO {{." + dbflex.ConfigKeyTableName + "}} " +
-			"({{.FIELDS}}) VALUES ({{.VALUES}})",
-		dbflex.QueryUpdate: "UPDATE {{." + dbflex.ConfigKeyTableName + "}} " +
-			"SET {{.FIELDVALUES}} {{." + dbflex.QueryWhere + "}}",
-		dbflex.QueryDelete: "DELETE FROM {{." + dbflex.ConfigKeyTableName + "}} " +
-			"{{." + dbflex.QueryWhere + "}}",
-		dbflex.AggrMax:         "MAX({{.FIELD}})",
-		dbflex.AggrMin:         "MIN({{.FIELD}})",
-		string(dbflex.AggrSum): "SUM({{.FIELD}})",
-		dbflex.AggrCount:       "COUNT(*)",
-		dbflex.AggrAvg:         "AVG({{.FIELD}})",
+// Dialect returns dialect used to generate the command, BaseDialect if it is not set
+func (q *Query) Dialect() Dialect {
+	if q.dialect == nil {
+		q.dialect = new(BaseDialect)
+	}
+	return q.dialect
+}
+
+// BuildFilter returns the filter as is, it is translated when the statement is built
+// so its placeholders are numbered after the ones of SET clause
+func (q *Query) BuildFilter(f *dbflex.Filter) (interface{}, error) {
+	return f, nil
+}
+
+// BuildCommand returns command of select and delete. Command of insert, update and save
+// depends on the data, it is built by Statement on execution
+func (q *Query) BuildCommand() (interface{}, error) {
+	ct := q.Config(dbflex.ConfigKeyCommandType, "").(string)
+	if ct != dbflex.QuerySQL && q.Config(dbflex.ConfigKeyTableName, "").(string) == "" {
+		return nil, toolkit.Errorf("Table must be specified")
+	}
+
+	switch ct {
+	case dbflex.QueryInsert, dbflex.QuerySave, dbflex.QueryUpdate:
+		return "", nil
 	}
+	cmdtxt, _, err := q.Statement(nil)
+	return cmdtxt, err
 }
 
-func (q *Query) buildCommandTemplate(data toolkit.M) (string, error) {
-	cmdType, ok := q.Config(dbflex.ConfigKeyCommandType, dbflex.QuerySelect).(string)
-	if !ok {
-		return "", toolkit.Errorf("Operation is not known. current operation is %s", cmdType)
-	}
-	commands := q.Templates()
-	templateTxt := commands[string(cmdType)]
-
-	if cmdType == dbflex.QuerySelect {
-		fields := data.Get("fields", []string{}).([]string)
-		orderby := data.Get(dbflex.QueryOrder, "").(string)
-		groupby := data.Get(dbflex.QueryGroup, "").(string)
-		take := data.Get(dbflex.QueryTake, 0).(int)
-		skip := data.Get(dbflex.QueryTake, 0).(int)
-
-		if len(fields) == 0 {
-			data.Set("FIELDS", "*")
-		} else {
-			data.Set("FIELDS", strings.Join(fields, ","))
+// Statement returns SQL command and its arguments for parameter of Execute or Cursor
+func (q *Query) Statement(in toolkit.M) (string, []interface{}, error) {
+	parts := q.Config(dbflex.ConfigKeyGroupedQueryItems, dbflex.GroupedQueryItems{}).(dbflex.GroupedQueryItems)
+	ct := q.Config(dbflex.ConfigKeyCommandType, dbflex.QuerySelect).(string)
+	filter, _ := q.Config(dbflex.ConfigKeyFilter, nil).(*dbflex.Filter)
+	s := &statement{dialect: q.Dialect()}
+	tablename := s.dialect.Quote(q.Config(dbflex.ConfigKeyTableName, "").(string))
+
+	switch ct {
+	case dbflex.QuerySQL:
+		return parts[dbflex.QuerySQL][0].Value.(string), nil, nil
+
+	case dbflex.QuerySelect:
+		return q.selectStatement(s, parts, tablename, filter)
+
+	case dbflex.QueryDelete:
+		where, err := s.where(filter)
+		if err != nil {
+			return "", nil, err
 		}
+		return "DELETE FROM " + tablename + where, s.args, nil
 
-		if orderby != "" {
-			data.Set(dbflex.QueryOrder,
-				executeTemplate(commands[dbflex.QueryOrder],
-					toolkit.M{}.Set(dbflex.QueryOrder, orderby)))
-		} else {
-			data.Set(dbflex.QueryOrder, "")
+	case dbflex.QueryUpdate:
+		sets := []string{}
+		if in.Has("data") {
+			names, values, err := q.dataValues(in.Get("data"))
+			if err != nil {
+				return "", nil, err
+			}
+			for idx, name := range names {
+				sets = append(sets, s.field(name)+" = "+s.bind(values[idx]))
+			}
+		}
+		updates, err := q.updateItems(s, parts)
+		if err != nil {
+			return "", nil, err
+		}
+		sets = append(sets, updates...)
+		if len(sets) == 0 {
+			return "", nil, toolkit.Error("update need to have data or update items")
 		}
 
-		if groupby != "" {
-			data.Set(dbflex.QueryGroup,
-				executeTemplate(commands[dbflex.QueryGroup],
-					toolkit.M{}.Set(dbflex.QueryGroup, groupby)))
-		} else {
-			data.Set(dbflex.QueryGroup, "")
+		where, err := s.where(filter)
+		if err != nil {
+			return "", nil, err
 		}
+		return "UPDATE " + tablename + " SET " + strings.Join(sets, ", ") + where, s.args, nil
 
-		if take != 0 {
-			data.Set(dbflex.QueryTake,
-				executeTemplate(commands[dbflex.QueryTake],
-					toolkit.M{}.Set(dbflex.QueryTake, take)))
-		} else {
-			data.Set(dbflex.QueryTake, "")
+	case dbflex.QueryInsert, dbflex.QuerySave:
+		if !in.Has("data") {
+			return "", nil, toolkit.Errorf("%s command should has data", ct)
+		}
+		data := in.Get("data")
+		names, values, err := q.dataValues(data)
+		if err != nil {
+			return "", nil, err
+		}
+		keys := KeyColumns(data, names, s.dialect)
+		if ct == dbflex.QuerySave && len(keys) == 0 {
+			return "", nil, toolkit.Errorf("save need a key, %T has no Id method or id field", data)
 		}
 
-		if skip != 0 {
-			data.Set(dbflex.QuerySkip,
-				executeTemplate(commands[dbflex.QuerySkip],
-					toolkit.M{}.Set(dbflex.QuerySkip, skip)))
-		} else {
-			data.Set(dbflex.QuerySkip, "")
+		columns := []string{}
+		placeholders := []string{}
+		for idx, name := range names {
+			if ct == dbflex.QueryInsert && hasString(keys, name) && s.dialect.SerialKey(values[idx]) {
+				continue
+			}
+			columns = append(columns, s.field(name))
+			placeholders = append(placeholders, s.bind(values[idx]))
 		}
-	} else if cmdType == dbflex.QueryInsert {
-		data.Set("FIELDS", "{{.FIELDS}}").Set("VALUES", "{{.VALUES}}")
-	} else if cmdType == dbflex.QueryUpdate {
-		data.Set("FIELDVALUES", "{{.FIELDVALUES}}")
-	}
 
-	var buff bytes.Buffer
-	tmp, err := template.New("main").Parse(templateTxt)
-	if err != nil {
-		return "", toolkit.Errorf("parsing template error. %s. template: %s", err.Error(), templateTxt)
-	}
-	err = tmp.Execute(&buff, data)
-	if err != nil {
-		return "", toolkit.Errorf("execute template error. %s", err.Error())
+		cmdtxt := "INSERT INTO " + tablename + " (" + strings.Join(columns, ", ") + ") VALUES (" +
+			strings.Join(placeholders, ", ") + ")"
+		if ct == dbflex.QuerySave {
+			cmdtxt = s.dialect.Upsert(cmdtxt, columns, s.fields(keys))
+		}
+		if len(keys) > 0 {
+			if returning := s.dialect.Returning(s.fields(keys)); returning != "" {
+				cmdtxt += " " + returning
+			}
+		}
+		return cmdtxt, s.args, nil
 	}
 
-	return strings.Trim(buff.String(), " "), nil
+	return "", nil, toolkit.Errorf("Operation is unknown. current operation is %s", ct)
 }
 
-func executeTemplate(templateTxt string, data toolkit.M) string {
-	var buff bytes.Buffer
-	tmp, err := template.New("main").Parse(templateTxt)
-	if err != nil {
-		return templateTxt
-	}
-	err = tmp.Execute(&buff, data)
-	if err != nil {
-		return templateTxt
+func (q *Query) selectStatement(s *statement, parts dbflex.GroupedQueryItems, tablename string,
+	filter *dbflex.Filter) (string, []interface{}, error) {
+	fields := []string{}
+	if items, ok := parts[dbflex.QuerySelect]; ok {
+		fields = s.fields(items[0].Value.([]string))
 	}
-	return buff.String()
-}
-
-func (q *Query) BuildFilter(f *dbflex.Filter) (interface{}, error) {
-	ret := ""
 
-	//panic("not implemented")
-	sqlfmts := []string{}
-	if f.Value != nil {
-		_, _, _, sqlfmts = ParseSQLMetadata(f.Value)
+	groups := []string{}
+	for _, item := range parts[dbflex.QueryGroup] {
+		for _, field := range item.Value.([]string) {
+			if strings.TrimSpace(field) != "" {
+				groups = append(groups, s.field(field))
+			}
+		}
 	}
 
-	switch f.Op {
-	case dbflex.OpAnd, dbflex.OpOr:
-		txts := []string{}
-		for _, item := range f.Items {
-			if txt, err := q.BuildFilter(item); err != nil {
-				return ret, err
-			} else {
-				txts = append(txts, txt.(string))
+	if items, ok := parts[dbflex.QueryAggr]; ok {
+		fields = append([]string{}, groups...)
+		for _, item := range items[0].Value.([]*dbflex.AggrItem) {
+			alias := item.Alias
+			if alias == "" {
+				alias = item.Field
+			}
+			expr := ""
+			switch item.Op {
+			case dbflex.AggrCount:
+				expr = "COUNT(*)"
+			case dbflex.AggrSum:
+				expr = "SUM(" + s.field(item.Field) + ")"
+			case dbflex.AggrAvg:
+				expr = "AVG(" + s.field(item.Field) + ")"
+			case dbflex.AggrMin:
+				expr = "MIN(" + s.field(item.Field) + ")"
+			case dbflex.AggrMax:
+				expr = "MAX(" + s.field(item.Field) + ")"
+			default:
+				return "", nil, toolkit.Errorf("aggregation op %s is not supported", item.Op)
 			}
+			if alias != "" {
+				expr += " AS " + s.dialect.Quote(alias)
+			}
+			fields = append(fields, expr)
 		}
-		ret = strings.Join(txts, toolkit.IfEq(f.Op, dbflex.OpAnd, " and ", " or ").(string))
+	}
+	if len(fields) == 0 {
+		fields = []string{"*"}
+	}
 
-	case dbflex.OpContains:
-		contains := f.Value.([]string)
-		rets := []string{}
-		for _, contain := range contains {
-			rets = append(rets, f.Field+" like '%"+contain+"%'")
-		}
-		ret = strings.Join(rets, " or ")
+	cmdtxt := "SELECT " + strings.Join(fields, ", ") + " FROM " + tablename
+	where, err := s.where(filter)
+	if err != nil {
+		return "", nil, err
+	}
+	cmdtxt += where
 
-	case dbflex.OpEndWith:
-		ret = f.Field + " like '" + f.Value.(string) + "%'"
+	if len(groups) > 0 {
+		cmdtxt += " GROUP BY " + strings.Join(groups, ", ")
+	}
 
-	case dbflex.OpEq:
-		if strings.HasPrefix(sqlfmts[0], "'") {
-			ret = f.Field + " like " + sqlfmts[0]
-		} else {
-			ret = f.Field + " = " + sqlfmts[0]
+	orders := []string{}
+	for _, item := range parts[dbflex.QueryOrder] {
+		for _, field := range item.Value.([]string) {
+			field = strings.TrimSpace(field)
+			if strings.HasPrefix(field, "-") {
+				orders = append(orders, s.field(field[1:])+" DESC")
+			} else if field != "" {
+				orders = append(orders, s.field(field))
+			}
 		}
+	}
+	if len(orders) > 0 {
+		cmdtxt += " ORDER BY " + strings.Join(orders, ", ")
+	}
 
-	case dbflex.OpGt:
-		ret = f.Field + " > " + sqlfmts[0]
-
-	case dbflex.OpGte:
-		ret = f.Field + " >= " + sqlfmts[0]
+	take, skip := 0, 0
+	if items, ok := parts[dbflex.QueryTake]; ok {
+		take = items[0].Value.(int)
+	}
+	if items, ok := parts[dbflex.QuerySkip]; ok {
+		skip = items[0].Value.(int)
+	}
+	return s.dialect.Paging(cmdtxt, take, skip), s.args, nil
+}
 
-	case dbflex.OpLt:
-		ret = f.Field + " < " + sqlfmts[0]
+// dataValues returns column names and values of data, limited to fields of the command if any
+func (q *Query) dataValues(data interface{}) ([]string, []interface{}, error) {
+	names, values, err := ColumnValues(data, q.Dialect())
+	if err != nil {
+		return nil, nil, err
+	}
 
-	case dbflex.OpLte:
-		ret = f.Field + " <= " + sqlfmts[0]
+	fields := q.Config("fields", []string{}).([]string)
+	if len(fields) == 0 {
+		return names, values, nil
+	}
 
-	case dbflex.OpIn:
-		items := []string{}
-		for _, v := range sqlfmts {
-			item := ""
-			if strings.HasPrefix(sqlfmts[0], "'") {
-				item = f.Field + " like " + v
-			} else {
-				item = f.Field + " = " + v
+	newnames := []string{}
+	newvalues := []interface{}{}
+	for _, find := range fields {
+		for idx, name := range names {
+			if strings.ToLower(name) == strings.ToLower(find) {
+				newnames = append(newnames, name)
+				newvalues = append(newvalues, values[idx])
 			}
-			items = append(items, item)
 		}
-		ret = strings.Join(items, " or ")
+	}
+	return newnames, newvalues, nil
+}
 
-	case dbflex.OpNe:
-		if strings.HasPrefix(sqlfmts[0], "'") {
-			ret = f.Field + " not like " + sqlfmts[0]
-		} else {
-			ret = f.Field + " != " + sqlfmts[0]
-		}
+// BuildUpdateItems returns SET expressions of update items defined by Modify and their arguments.
+// Argument is marked by placeholder of the dialect
+func (q *Query) BuildUpdateItems() ([]string, []interface{}, error) {
+	parts := q.Config(dbflex.ConfigKeyGroupedQueryItems, dbflex.GroupedQueryItems{}).(dbflex.GroupedQueryItems)
+	s := &statement{dialect: q.Dialect()}
+	exprs, err := q.updateItems(s, parts)
+	return exprs, s.args, err
+}
 
-	case dbflex.OpNin:
-		items := []string{}
-		for _, v := range sqlfmts {
-			item := ""
-			if strings.HasPrefix(sqlfmts[0], "'") {
-				item = f.Field + " not like " + v
-			} else {
-				item = f.Field + " != " + v
+// updateItems returns SET expressions of update items defined by Modify
+func (q *Query) updateItems(s *statement, parts dbflex.GroupedQueryItems) ([]string, error) {
+	exprs := []string{}
+	for _, qi := range parts[dbflex.QueryModify] {
+		for _, item := range qi.Value.([]*dbflex.UpdateItem) {
+			field := s.field(item.Field)
+			switch item.Op {
+			case dbflex.UpdateSet:
+				exprs = append(exprs, field+" = "+s.bind(item.Value))
+			case dbflex.UpdateInc:
+				exprs = append(exprs, field+" = "+field+" + "+s.bind(item.Value))
+			case dbflex.UpdateMul:
+				exprs = append(exprs, field+" = "+field+" * "+s.bind(item.Value))
+			case dbflex.UpdateCurrentDate:
+				exprs = append(exprs, field+" = CURRENT_TIMESTAMP")
+			case dbflex.UpdateUnset:
+				exprs = append(exprs, field+" = NULL")
+			default:
+				return exprs, toolkit.Errorf("update op %s is not supported", item.Op)
 			}
-			items = append(items, item)
 		}
-		ret = strings.Join(items, " and ")
-
-	case dbflex.OpRange:
-		//ret = toolkit.Sprintf("%s >= %s and %s <= %s",
-		//	f.Field, sqlfmts[0], f.Field, sqlfmts[1])
-		ret = f.Field + " between " + sqlfmts[0] + " and " + sqlfmts[1]
 	}
-
-	return ret, nil
+	return exprs, nil
 }
 
-func (q *Query) BuildCommand() (interface{}, error) {
-	parts := q.Config(dbflex.ConfigKeyGroupedQueryItems, dbflex.GroupedQueryItems{}).(dbflex.GroupedQueryItems)
+// OpenCursor runs select command of the query and attaches its rows into cursor c.
+// A driver calls it from its Cursor method using its own cursor type
+func (q *Query) OpenCursor(c *Cursor, in toolkit.M) dbflex.ICursor {
+	ct := q.Config(dbflex.ConfigKeyCommandType, dbflex.QuerySelect).(string)
+	if ct != dbflex.QuerySelect && ct != dbflex.QuerySQL {
+		c.SetError(toolkit.Errorf("cursor is used for only select command"))
+		return c.this()
+	}
 
-	ct := q.Config(dbflex.ConfigKeyCommandType, "")
-	if ct == dbflex.QuerySQL {
-		items := parts[dbflex.QuerySQL]
-		return items[0].Value.(string), nil
+	cmdtxt, args, err := q.Statement(in)
+	if err != nil {
+		c.SetError(err)
+		return c.this()
 	}
 
-	commandData := toolkit.M{}
 	tablename := q.Config(dbflex.ConfigKeyTableName, "").(string)
-	if len(tablename) == 0 {
-		return nil, toolkit.Errorf("Table must be specified")
+	cq := dbflex.From(tablename).Aggr(dbflex.NewAggrItem("Count", dbflex.AggrCount, ""))
+	if filter := q.Config(dbflex.ConfigKeyFilter, nil); filter != nil {
+		cq.Where(filter.(*dbflex.Filter))
 	}
-	commandData.Set(dbflex.ConfigKeyTableName, tablename)
+	c.SetCountCommand(cq)
 
-	where := q.Config(dbflex.ConfigKeyWhere, "").(string)
-	if strings.Trim(where, " ") == "" {
-		commandData.Set(dbflex.QueryWhere, "")
+	rows, err := q.db.Query(cmdtxt, args...)
+	if rows == nil {
+		c.SetError(toolkit.Errorf("%s. SQL Command: %s", err.Error(), cmdtxt))
 	} else {
-		commandData.Set(dbflex.QueryWhere, "WHERE "+where)
+		c.SetFetcher(rows)
 	}
+	return c.this()
+}
 
-	switch ct {
-	case dbflex.QuerySelect:
-		if items, ok := parts[dbflex.QuerySelect]; ok {
-			commandData.Set("fields", items[0].Value.([]string))
-		}
-		if items, ok := parts[dbflex.QueryTake]; ok {
-			commandData.Set(dbflex.QueryTake, items[0].Value.(int))
-		}
-		if items, ok := parts[dbflex.QuerySkip]; ok {
-			commandData.Set(dbflex.QuerySkip, items[0].Value.(int))
-		}
+// Execute will executes non-select command of a query. Insert and save return value of the key
+// when dialect supports returning clause, otherwise sql.Result is returned
+func (q *Query) Execute(in toolkit.M) (interface{}, error) {
+	ct := q.Config(dbflex.ConfigKeyCommandType, dbflex.QuerySelect).(string)
+	if ct == dbflex.QuerySelect {
+		return nil, toolkit.Errorf("select command should use cursor instead of execute")
+	}
 
-		if items, ok := parts[dbflex.QueryOrder]; ok {
-			fields := []string{}
-			for _, v := range items {
-				orderfields := v.Value.([]string)
-				for _, orderfield := range orderfields {
-					if !strings.HasPrefix(orderfield, "-") {
-						fields = append(fields, strings.TrimSpace(orderfield))
-					} else {
-						orderfield = orderfield[1:]
-						fields = append(fields, strings.TrimSpace(orderfield)+" desc")
-					}
-				}
-			}
-			if len(fields) > 0 {
-				commandData.Set(dbflex.QueryOrder, strings.Join(fields, ","))
-			}
-		} else {
-			commandData.Set(dbflex.QueryOrder, "")
-		}
+	cmdtxt, args, err := q.Statement(in)
+	if err != nil {
+		return nil, err
+	}
 
-		if part, ok := parts[dbflex.QueryAggr]; ok {
-			items := part[0].Value.([]*dbflex.AggrItem)
-			fields := []string{}
-			for _, item := range items {
-				field := ""
-				if item.Alias == "" {
-					item.Alias = item.Field
-				}
-				switch item.Op {
-				case dbflex.AggrCount:
-					field = toolkit.Sprintf("COUNT(*) as %s", item.Alias)
-
-				case dbflex.AggrMax:
-					field = toolkit.Sprintf("MAX(%s) as %s", item.Field, item.Alias)
-
-				case dbflex.AggrMin:
-					field = toolkit.Sprintf("MIN(%s) as %s", item.Field, item.Alias)
-
-				case dbflex.AggrAvg:
-					field = toolkit.Sprintf("AVG(%s) as %s", item.Field, item.Alias)
-
-				case dbflex.AggrSum:
-					field = toolkit.Sprintf("SUM(%s) as %s", item.Field, item.Alias)
-				}
-				if field != "" {
-					fields = append(fields, field)
-				}
-			}
-			commandData.Set("fields", fields)
-		}
+	if (ct == dbflex.QueryInsert || ct == dbflex.QuerySave) && strings.Contains(cmdtxt, " RETURNING ") {
+		return q.queryKeys(cmdtxt, args)
+	}
 
-		if groupby, ok := parts[dbflex.QueryGroup]; ok {
-			groupbyStr := func() string {
-				s := "GROUP BY "
-				fields := []string{}
-				for _, v := range groupby {
-					gs := v.Value.([]string)
-					for _, g := range gs {
-						if strings.TrimSpace(g) != "" {
-							fields = append(fields, g)
-						}
-					}
-				}
-				if len(fields) == 0 {
-					return ""
-				}
-				return s + strings.Join(fields, ",")
-			}()
-			commandData.Set(dbflex.QueryGroup, groupbyStr)
-		}
+	r, err := q.db.Exec(cmdtxt, args...)
+	if err != nil {
+		return nil, toolkit.Errorf("%s. SQL Command: %s", err.Error(), cmdtxt)
+	}
+	return r, nil
+}
 
-	case dbflex.QueryInsert:
-		if items, ok := parts[dbflex.QueryInsert]; ok {
-			fields := items[0].Value.([]string)
-			if len(fields) > 0 {
-				//commandData.Set("fields", strings.Join(fields, ","))
-			}
-		}
+// queryKeys runs insert command with returning clause, it returns the key or slice of keys
+func (q *Query) queryKeys(cmdtxt string, args []interface{}) (interface{}, error) {
+	rows, err := q.db.Query(cmdtxt, args...)
+	if err != nil {
+		return nil, toolkit.Errorf("%s. SQL Command: %s", err.Error(), cmdtxt)
+	}
+	defer rows.Close()
 
-	case dbflex.QueryUpdate:
-		if items, ok := parts[dbflex.QueryUpdate]; ok {
-			fields := items[0].Value.([]string)
-			if len(fields) > 0 {
-				q.SetConfig("fields", fields)
-			}
+	columns, _ := rows.Columns()
+	keys := make([]interface{}, len(columns))
+	ptrs := make([]interface{}, len(columns))
+	for idx := range keys {
+		ptrs[idx] = &keys[idx]
+	}
+	if rows.Next() {
+		if err = rows.Scan(ptrs...); err != nil {
+			return nil, toolkit.Errorf("%s. SQL Command: %s", err.Error(), cmdtxt)
 		}
 	}
+	if err = rows.Err(); err != nil {
+		return nil, toolkit.Errorf("%s. SQL Command: %s", err.Error(), cmdtxt)
+	}
 
-	cmdTxt, err := q.buildCommandTemplate(commandData)
-
-	//toolkit.Printfn("Command: %s", cmdTxt)
-	return cmdTxt, err
+	for idx, key := range keys {
+		if bs, ok := key.([]byte); ok {
+			keys[idx] = string(bs)
+		}
+	}
+	if len(keys) == 1 {
+		return keys[0], nil
+	}
+	return keys, nil
 }
 
-//ParseSQLMetadata returns names, types, values and sql value as string
+// ParseSQLMetadata returns names, types, values and sql value as string. Values are written
+// as literal of BaseDialect
 func ParseSQLMetadata(o interface{}) ([]string, []reflect.Type, []interface{}, []string) {
 	names := []string{}
 	types := []reflect.Type{}
@@ -361,6 +396,7 @@ func ParseSQLMetadata(o interface{}) ([]string, []reflect.Type, []interface{}, [
 		return names, types, values, sqlnames
 	}
 
+	d := new(BaseDialect)
 	r := reflect.Indirect(reflect.ValueOf(o))
 	t := r.Type()
 
@@ -378,7 +414,7 @@ func ParseSQLMetadata(o interface{}) ([]string, []reflect.Type, []interface{}, [
 			}
 			types = append(types, ft.Type)
 			values = append(values, v)
-			sqlnames = append(sqlnames, sqlFormat(v))
+			sqlnames = append(sqlnames, Literal(d, v))
 		}
 	} else if r.Kind() == reflect.Map {
 		keys := r.MapKeys()
@@ -389,40 +425,14 @@ func ParseSQLMetadata(o interface{}) ([]string, []reflect.Type, []interface{}, [
 			value := r.MapIndex(k)
 			v := value.Interface()
 			values = append(values, v)
-			sqlnames = append(sqlnames, sqlFormat(v))
+			sqlnames = append(sqlnames, Literal(d, v))
 		}
 	} else {
 		names = append(names, t.Name())
 		types = append(types, t)
 		values = append(values, o)
-		sqlnames = append(sqlnames, sqlFormat(o))
+		sqlnames = append(sqlnames, Literal(d, o))
 	}
 
 	return names, types, values, sqlnames
 }
-
-// valueTrace returns sqlvalues
-func sqlFormat(v interface{}) string {
-	if s, ok := v.(string); ok {
-		return toolkit.Sprintf("'%s'", s)
-	} else if _, ok := v.(int); ok {
-		return toolkit.Sprintf("%d", v)
-	} else if _, ok = v.(float64); ok {
-		return toolkit.Sprintf("%f", v)
-	} else if _, ok = v.(time.Time); ok {
-		return toolkit.Date2String(v.(time.Time), "'yyyy-MM-dd hh:mm:ss'")
-	} else if b, ok := v.(bool); ok {
-		if b {
-			return "1"
-		} else {
-			return "0"
-		}
-	} else {
-		vstr := toolkit.Sprintf("%v", v)
-		if _, err := strconv.ParseFloat(vstr, 64); err == nil {
-			return vstr
-		} else {
-			return "'" + vstr + "'"
-		}
-	}
-}
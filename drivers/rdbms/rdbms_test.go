@@ -0,0 +1,222 @@
+package rdbms_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eaciit/dbflex"
+	"github.com/eaciit/dbflex/drivers/mysql"
+	"github.com/eaciit/dbflex/drivers/postgres"
+	"github.com/eaciit/dbflex/drivers/rdbms"
+	"github.com/eaciit/dbflex/drivers/sqlite"
+	"github.com/eaciit/toolkit"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// connection prepares rdbms.Query of a dialect without a database
+type connection struct {
+	dbflex.ConnectionBase
+	dialect rdbms.Dialect
+}
+
+func (c *connection) State() string {
+	return dbflex.StateConnected
+}
+
+func (c *connection) NewQuery() dbflex.IQuery {
+	q := new(rdbms.Query)
+	q.SetThis(q)
+	q.SetDialect(c.dialect)
+	return q
+}
+
+var dialects = []struct {
+	name    string
+	dialect rdbms.Dialect
+}{
+	{"base", new(rdbms.BaseDialect)},
+	{"mysql", new(mysql.Dialect)},
+	{"sqlite", new(sqlite.Dialect)},
+	{"postgres", new(postgres.Dialect)},
+}
+
+type record struct {
+	ID     string `sqlname:"id"`
+	Name   string
+	Active bool
+}
+
+type grade struct {
+	ID   int `sqlname:"id"`
+	Name string
+}
+
+func TestStatement(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  dbflex.ICommand
+		in   toolkit.M
+		args []interface{}
+		sql  map[string]string
+	}{
+		{"select with filter", dbflex.From("employees").Select("id", "name").
+			Where(dbflex.And(dbflex.Eq("active", true), dbflex.Gt("grade", 3))), nil,
+			[]interface{}{true, 3}, map[string]string{
+				"base":     `SELECT "id", "name" FROM "employees" WHERE ("active" = ? AND "grade" > ?)`,
+				"mysql":    "SELECT `id`, `name` FROM `employees` WHERE (`active` = ? AND `grade` > ?)",
+				"sqlite":   `SELECT "id", "name" FROM "employees" WHERE ("active" = ? AND "grade" > ?)`,
+				"postgres": `SELECT "id", "name" FROM "employees" WHERE ("active" = $1 AND "grade" > $2)`,
+			}},
+		{"order take skip", dbflex.From("employees").Select().OrderBy("-name", "id").Take(10).Skip(5), nil,
+			nil, map[string]string{
+				"base":     `SELECT * FROM "employees" ORDER BY "name" DESC, "id" LIMIT 10 OFFSET 5`,
+				"mysql":    "SELECT * FROM `employees` ORDER BY `name` DESC, `id` LIMIT 10 OFFSET 5",
+				"sqlite":   `SELECT * FROM "employees" ORDER BY "name" DESC, "id" LIMIT 10 OFFSET 5`,
+				"postgres": `SELECT * FROM "employees" ORDER BY "name" DESC, "id" LIMIT 10 OFFSET 5`,
+			}},
+		{"skip only", dbflex.From("employees").Select().Skip(5), nil,
+			nil, map[string]string{
+				"base":     `SELECT * FROM "employees" OFFSET 5`,
+				"mysql":    "SELECT * FROM `employees` LIMIT 18446744073709551615 OFFSET 5",
+				"sqlite":   `SELECT * FROM "employees" LIMIT -1 OFFSET 5`,
+				"postgres": `SELECT * FROM "employees" OFFSET 5`,
+			}},
+		{"like escape", dbflex.From("employees").Select().Where(dbflex.Contains("name", "5%_")), nil,
+			[]interface{}{`%5\%\_%`}, map[string]string{
+				"base":     `SELECT * FROM "employees" WHERE "name" LIKE ? ESCAPE '\'`,
+				"mysql":    "SELECT * FROM `employees` WHERE `name` LIKE ?",
+				"sqlite":   `SELECT * FROM "employees" WHERE "name" LIKE ? ESCAPE '\'`,
+				"postgres": `SELECT * FROM "employees" WHERE "name" ILIKE $1`,
+			}},
+		{"in and empty nin", dbflex.From("employees").Select().
+			Where(dbflex.And(dbflex.In("grade", 1, 2), dbflex.Nin("id"))), nil,
+			[]interface{}{1, 2}, map[string]string{
+				"base":     `SELECT * FROM "employees" WHERE ("grade" IN (?, ?) AND 1 = 1)`,
+				"mysql":    "SELECT * FROM `employees` WHERE (`grade` IN (?, ?) AND 1 = 1)",
+				"sqlite":   `SELECT * FROM "employees" WHERE ("grade" IN (?, ?) AND 1 = 1)`,
+				"postgres": `SELECT * FROM "employees" WHERE ("grade" IN ($1, $2) AND 1 = 1)`,
+			}},
+		{"aggregate", dbflex.From("employees").Aggr(dbflex.Sum("salary")).GroupBy("grade"), nil,
+			nil, map[string]string{
+				"base":     `SELECT "grade", SUM("salary") AS "salary" FROM "employees" GROUP BY "grade"`,
+				"mysql":    "SELECT `grade`, SUM(`salary`) AS `salary` FROM `employees` GROUP BY `grade`",
+				"sqlite":   `SELECT "grade", SUM("salary") AS "salary" FROM "employees" GROUP BY "grade"`,
+				"postgres": `SELECT "grade", SUM("salary") AS "salary" FROM "employees" GROUP BY "grade"`,
+			}},
+		{"insert", dbflex.From("employees").Insert(), toolkit.M{}.Set("data", &record{"A", "Ann", true}),
+			[]interface{}{"A", "Ann", true}, map[string]string{
+				"base":     `INSERT INTO "employees" ("id", "Name", "Active") VALUES (?, ?, ?)`,
+				"mysql":    "INSERT INTO `employees` (`id`, `Name`, `Active`) VALUES (?, ?, ?)",
+				"sqlite":   `INSERT INTO "employees" ("id", "Name", "Active") VALUES (?, ?, ?)`,
+				"postgres": `INSERT INTO "employees" ("id", "name", "active") VALUES ($1, $2, $3) RETURNING "id"`,
+			}},
+		{"quote field with non identifier characters", dbflex.From("employees").Select("first name", "a`b").
+			Where(dbflex.Eq(`x"; DROP TABLE t; --`, 1)), nil,
+			[]interface{}{1}, map[string]string{
+				"base":     `SELECT "first name", "a` + "`" + `b" FROM "employees" WHERE "x""; DROP TABLE t; --" = ?`,
+				"mysql":    "SELECT `first name`, `a``b` FROM `employees` WHERE `x\"; DROP TABLE t; --` = ?",
+				"sqlite":   `SELECT "first name", "a` + "`" + `b" FROM "employees" WHERE "x""; DROP TABLE t; --" = ?`,
+				"postgres": `SELECT "first name", "a` + "`" + `b" FROM "employees" WHERE "x""; DROP TABLE t; --" = $1`,
+			}},
+		{"save", dbflex.From("employees").Save(), toolkit.M{}.Set("data", &record{"A", "Ann", true}),
+			[]interface{}{"A", "Ann", true}, map[string]string{
+				"base": `INSERT INTO "employees" ("id", "Name", "Active") VALUES (?, ?, ?) ` +
+					`ON CONFLICT ("id") DO UPDATE SET "Name" = EXCLUDED."Name", "Active" = EXCLUDED."Active"`,
+				"mysql": "INSERT INTO `employees` (`id`, `Name`, `Active`) VALUES (?, ?, ?) " +
+					"ON DUPLICATE KEY UPDATE `Name` = VALUES(`Name`), `Active` = VALUES(`Active`)",
+				"sqlite": `INSERT INTO "employees" ("id", "Name", "Active") VALUES (?, ?, ?) ` +
+					`ON CONFLICT ("id") DO UPDATE SET "Name" = EXCLUDED."Name", "Active" = EXCLUDED."Active"`,
+				"postgres": `INSERT INTO "employees" ("id", "name", "active") VALUES ($1, $2, $3) ` +
+					`ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name", "active" = EXCLUDED."active" RETURNING "id"`,
+			}},
+		{"update and modify", dbflex.From("employees").Where(dbflex.Eq("id", "A")).Update("name").
+			Modify(dbflex.Inc("salary", 10)), toolkit.M{}.Set("data", &record{"A", "Ann", true}),
+			[]interface{}{"Ann", 10, "A"}, map[string]string{
+				"base":     `UPDATE "employees" SET "Name" = ?, "salary" = "salary" + ? WHERE "id" = ?`,
+				"mysql":    "UPDATE `employees` SET `Name` = ?, `salary` = `salary` + ? WHERE `id` = ?",
+				"sqlite":   `UPDATE "employees" SET "Name" = ?, "salary" = "salary" + ? WHERE "id" = ?`,
+				"postgres": `UPDATE "employees" SET "name" = $1, "salary" = "salary" + $2 WHERE "id" = $3`,
+			}},
+		{"delete", dbflex.From("employees").Where(dbflex.Lte("grade", 2)).Delete(), nil,
+			[]interface{}{2}, map[string]string{
+				"base":     `DELETE FROM "employees" WHERE "grade" <= ?`,
+				"mysql":    "DELETE FROM `employees` WHERE `grade` <= ?",
+				"sqlite":   `DELETE FROM "employees" WHERE "grade" <= ?`,
+				"postgres": `DELETE FROM "employees" WHERE "grade" <= $1`,
+			}},
+	}
+
+	for _, d := range dialects {
+		Convey("Generate SQL of "+d.name, t, func() {
+			conn := &connection{dialect: d.dialect}
+			conn.SetThis(conn)
+
+			for _, test := range tests {
+				Convey(test.name, func() {
+					q, err := conn.Prepare(test.cmd)
+					So(err, ShouldBeNil)
+					cmdtxt, args, err := q.(*rdbms.Query).Statement(test.in)
+					So(err, ShouldBeNil)
+					So(cmdtxt, ShouldEqual, test.sql[d.name])
+					if test.args == nil {
+						So(len(args), ShouldEqual, 0)
+					} else {
+						So(args, ShouldResemble, test.args)
+					}
+				})
+			}
+
+			Convey("update items", func() {
+				q, err := conn.Prepare(dbflex.From("employees").Update().Modify(dbflex.Inc("salary", 10)))
+				So(err, ShouldBeNil)
+				items, args, err := q.(*rdbms.Query).BuildUpdateItems()
+				So(err, ShouldBeNil)
+				So(len(items), ShouldEqual, 1)
+				So(args, ShouldResemble, []interface{}{10})
+			})
+
+			Convey("insert zero serial key", func() {
+				q, err := conn.Prepare(dbflex.From("grades").Insert())
+				So(err, ShouldBeNil)
+				cmdtxt, args, err := q.(*rdbms.Query).Statement(toolkit.M{}.Set("data", &grade{0, "A"}))
+				So(err, ShouldBeNil)
+				So(cmdtxt, ShouldEqual, map[string]string{
+					"base":     `INSERT INTO "grades" ("Name") VALUES (?)`,
+					"mysql":    "INSERT INTO `grades` (`id`, `Name`) VALUES (?, ?)",
+					"sqlite":   `INSERT INTO "grades" ("Name") VALUES (?)`,
+					"postgres": `INSERT INTO "grades" ("name") VALUES ($1) RETURNING "id"`,
+				}[d.name])
+				if d.name == "mysql" {
+					So(args, ShouldResemble, []interface{}{0, "A"})
+				} else {
+					So(args, ShouldResemble, []interface{}{"A"})
+				}
+			})
+		})
+	}
+}
+
+func TestLiteral(t *testing.T) {
+	date := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name  string
+		value interface{}
+		sql   map[string]string
+	}{
+		{"bool", true, map[string]string{"base": "TRUE", "mysql": "1", "sqlite": "1", "postgres": "TRUE"}},
+		{"date", date, map[string]string{"base": "'2020-01-02 03:04:05'", "mysql": "'2020-01-02 03:04:05'",
+			"sqlite": "'2020-01-02 03:04:05'", "postgres": "'2020-01-02 03:04:05'"}},
+		{"string", `it's \`, map[string]string{"base": `'it''s \'`, "mysql": `'it''s \\'`,
+			"sqlite": `'it''s \'`, "postgres": `'it''s \'`}},
+		{"number", 1.5, map[string]string{"base": "1.5", "mysql": "1.5", "sqlite": "1.5", "postgres": "1.5"}},
+		{"null", nil, map[string]string{"base": "NULL", "mysql": "NULL", "sqlite": "NULL", "postgres": "NULL"}},
+	}
+
+	Convey("Write literal", t, func() {
+		for _, d := range dialects {
+			for _, test := range tests {
+				So(rdbms.Literal(d.dialect, test.value), ShouldEqual, test.sql[d.name])
+			}
+		}
+	})
+}
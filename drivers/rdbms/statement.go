@@ -0,0 +1,264 @@
+package rdbms
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/eaciit/dbflex"
+	"github.com/eaciit/toolkit"
+)
+
+// statement collects arguments of a command, each argument is written as placeholder of the dialect
+type statement struct {
+	dialect Dialect
+	args    []interface{}
+}
+
+func (s *statement) bind(v interface{}) string {
+	s.args = append(s.args, v)
+	return s.dialect.Placeholder(len(s.args))
+}
+
+// field quotes a field name, a name is never written into the command unquoted
+func (s *statement) field(name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" || name == "*" {
+		return name
+	}
+	return s.dialect.Quote(name)
+}
+
+func (s *statement) fields(names []string) []string {
+	quoted := make([]string, len(names))
+	for idx, name := range names {
+		quoted[idx] = s.field(name)
+	}
+	return quoted
+}
+
+// like binds a LIKE pattern, wildcard characters of value are escaped
+func (s *statement) like(field, prefix, value, suffix string) string {
+	esc := s.dialect.LikeEscape()
+	value = strings.NewReplacer(esc, esc+esc, "%", esc+"%", "_", esc+"_").Replace(value)
+	return s.dialect.Like(field, s.bind(prefix+value+suffix))
+}
+
+func (s *statement) where(f *dbflex.Filter) (string, error) {
+	if f == nil || f.Op == "" {
+		return "", nil
+	}
+	txt, err := s.filter(f)
+	if err != nil || txt == "" {
+		return "", err
+	}
+	return " WHERE " + txt, nil
+}
+
+func (s *statement) filter(f *dbflex.Filter) (string, error) {
+	field := s.field(f.Field)
+
+	switch f.Op {
+	case dbflex.OpAnd, dbflex.OpOr:
+		txts := []string{}
+		for _, item := range f.Items {
+			txt, err := s.filter(item)
+			if err != nil {
+				return "", err
+			}
+			if txt != "" {
+				txts = append(txts, txt)
+			}
+		}
+		if f.Op == dbflex.OpAnd {
+			return group(txts, " AND "), nil
+		}
+		return group(txts, " OR "), nil
+
+	case dbflex.OpEq:
+		if f.Value == nil {
+			return field + " IS NULL", nil
+		}
+		return field + " = " + s.bind(f.Value), nil
+
+	case dbflex.OpNe:
+		if f.Value == nil {
+			return field + " IS NOT NULL", nil
+		}
+		return field + " <> " + s.bind(f.Value), nil
+
+	case dbflex.OpGt:
+		return field + " > " + s.bind(f.Value), nil
+
+	case dbflex.OpGte:
+		return field + " >= " + s.bind(f.Value), nil
+
+	case dbflex.OpLt:
+		return field + " < " + s.bind(f.Value), nil
+
+	case dbflex.OpLte:
+		return field + " <= " + s.bind(f.Value), nil
+
+	case dbflex.OpRange:
+		values := toInterfaceSlice(f.Value)
+		if len(values) != 2 {
+			return "", toolkit.Errorf("range filter of %s should have 2 values", f.Field)
+		}
+		return field + " BETWEEN " + s.bind(values[0]) + " AND " + s.bind(values[1]), nil
+
+	case dbflex.OpIn, dbflex.OpNin:
+		in := f.Op == dbflex.OpIn
+		values := toInterfaceSlice(f.Value)
+		if len(values) == 0 {
+			if in {
+				return "1 = 0", nil
+			}
+			return "1 = 1", nil
+		}
+		placeholders := make([]string, len(values))
+		for idx, v := range values {
+			placeholders[idx] = s.bind(v)
+		}
+		if in {
+			return field + " IN (" + strings.Join(placeholders, ", ") + ")", nil
+		}
+		return field + " NOT IN (" + strings.Join(placeholders, ", ") + ")", nil
+
+	case dbflex.OpContains:
+		txts := []string{}
+		for _, v := range toInterfaceSlice(f.Value) {
+			txts = append(txts, s.like(field, "%", toolkit.ToString(v), "%"))
+		}
+		return group(txts, " OR "), nil
+
+	case dbflex.OpStartWith:
+		return s.like(field, "", toolkit.ToString(f.Value), "%"), nil
+
+	case dbflex.OpEndWith:
+		return s.like(field, "%", toolkit.ToString(f.Value), ""), nil
+	}
+
+	return "", toolkit.Errorf("filter op %s is not supported", f.Op)
+}
+
+func group(txts []string, sep string) string {
+	if len(txts) > 1 {
+		return "(" + strings.Join(txts, sep) + ")"
+	}
+	return strings.Join(txts, sep)
+}
+
+// toInterfaceSlice returns values of a slice, a single slice argument of In and Nin is expanded
+func toInterfaceSlice(v interface{}) []interface{} {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return []interface{}{v}
+	}
+	values := make([]interface{}, rv.Len())
+	for idx := range values {
+		values[idx] = rv.Index(idx).Interface()
+	}
+	if len(values) == 1 {
+		if inner := reflect.ValueOf(values[0]); inner.Kind() == reflect.Slice && inner.Type().Elem().Kind() != reflect.Uint8 {
+			return toInterfaceSlice(values[0])
+		}
+	}
+	return values
+}
+
+// ColumnValues returns column names and values of data. Field of a struct is named by its sqlname tag
+// or by ColumnName of the dialect. Keys of a map are sorted
+func ColumnValues(data interface{}, d Dialect) ([]string, []interface{}, error) {
+	names := []string{}
+	values := []interface{}{}
+
+	rv := reflect.Indirect(reflect.ValueOf(data))
+	switch rv.Kind() {
+	case reflect.Struct:
+		structValues(rv, d, &names, &values)
+
+	case reflect.Map:
+		keys := rv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return toolkit.ToString(keys[i].Interface()) < toolkit.ToString(keys[j].Interface())
+		})
+		for _, k := range keys {
+			names = append(names, toolkit.ToString(k.Interface()))
+			values = append(values, rv.MapIndex(k).Interface())
+		}
+
+	default:
+		return names, values, toolkit.Errorf("data should be a struct or a map, got %T", data)
+	}
+	return names, values, nil
+}
+
+func structValues(rv reflect.Value, d Dialect, names *[]string, values *[]interface{}) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			structValues(rv.Field(i), d, names, values)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		*names = append(*names, columnName(field, d))
+		*values = append(*values, rv.Field(i).Interface())
+	}
+}
+
+func columnName(field reflect.StructField, d Dialect) string {
+	if sqlname := field.Tag.Get("sqlname"); sqlname != "" {
+		return sqlname
+	}
+	return d.ColumnName(field.Name)
+}
+
+// KeyColumns returns key columns of data. Id method of orm model is used if defined,
+// otherwise a column named id is the key
+func KeyColumns(data interface{}, names []string, d Dialect) []string {
+	keys := []string{}
+	if model, ok := data.(interface {
+		Id() ([]string, []interface{})
+	}); ok {
+		rt := reflect.Indirect(reflect.ValueOf(data)).Type()
+		fields, _ := model.Id()
+		for _, name := range fields {
+			if field, ok := rt.FieldByName(name); ok {
+				keys = append(keys, columnName(field, d))
+			} else {
+				keys = append(keys, name)
+			}
+		}
+		return keys
+	}
+
+	for _, name := range names {
+		if strings.ToLower(name) == "id" {
+			keys = append(keys, name)
+		}
+	}
+	return keys
+}
+
+func hasString(values []string, find string) bool {
+	for _, v := range values {
+		if v == find {
+			return true
+		}
+	}
+	return false
+}
+
+// isSerial returns true if value is zero of an integer, the key is then generated by the database
+func isSerial(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.IsZero()
+	}
+	return false
+}
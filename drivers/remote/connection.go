@@ -0,0 +1,167 @@
+package remote
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/eaciit/toolkit"
+
+	"github.com/eaciit/dbflex"
+)
+
+func init() {
+	//=== sample: remote://localhost:8080/hr?token=secret
+	dbflex.RegisterDriver("remote", func(si *dbflex.ServerInfo) dbflex.IConnection {
+		c := new(Connection)
+		c.ServerInfo = *si
+		c.SetThis(c)
+		return c
+	})
+}
+
+// DefaultTimeout is timeout of a call to the server, a streamed cursor is not limited by it
+var DefaultTimeout = 30 * time.Second
+
+// Connection of remote driver, each command is sent to a dbflex server that runs it on a connection
+// registered by the database name. Token is taken from token config, or from password of the uri
+type Connection struct {
+	dbflex.ConnectionBase
+
+	baseURL   string
+	token     string
+	client    *http.Client
+	connected bool
+}
+
+// Connect checks the connection on the server and takes its field name tag
+func (c *Connection) Connect() error {
+	if c.Host == "" || c.Database == "" {
+		return toolkit.Errorf("server address and connection name should be specified")
+	}
+
+	scheme := c.Config.GetString("scheme")
+	if scheme == "" {
+		scheme = "http"
+	}
+	c.baseURL = scheme + "://" + c.Host + "/" + c.Database
+	c.token = c.Config.GetString("token")
+	if c.token == "" {
+		c.token = c.Password
+	}
+	c.client = &http.Client{}
+
+	res := new(response)
+	if err := c.call("connect", new(request), res); err != nil {
+		return err
+	}
+	c.SetFieldNameTag(res.FieldNameTag)
+	c.connected = true
+	return nil
+}
+
+func (c *Connection) State() string {
+	if c.connected {
+		return dbflex.StateConnected
+	}
+	return dbflex.StateUnknown
+}
+
+func (c *Connection) Close() {
+	c.connected = false
+}
+
+func (c *Connection) NewQuery() dbflex.IQuery {
+	q := new(Query)
+	q.SetThis(q)
+	q.SetConnection(c)
+	return q
+}
+
+// Prepare builds the query and lets the server prepare it, so an invalid command fails before it is run
+func (c *Connection) Prepare(cmd dbflex.ICommand) (dbflex.IQuery, error) {
+	q, err := c.ConnectionBase.Prepare(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.call("prepare", &request{Command: q.(*Query).items()}, new(response)); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+func (c *Connection) ObjectNames(ot dbflex.ObjTypeEnum) []string {
+	res := new(response)
+	if err := c.call("objectnames", &request{ObjType: string(ot)}, res); err != nil || res.Names == nil {
+		return []string{}
+	}
+	return res.Names
+}
+
+func (c *Connection) ValidateTable(interface{}, bool) error {
+	return toolkit.Errorf("ValidateTable is not supported by remote driver")
+}
+
+func (c *Connection) DropTable(name string) error {
+	return c.call("droptable", &request{Table: name}, new(response))
+}
+
+// post sends a request to an action of the connection
+func (c *Connection) post(action string, req *request, timeout time.Duration) (*http.Response, error) {
+	if c.client == nil {
+		return nil, toolkit.Errorf("connection is not yet established")
+	}
+
+	bs, err := json.Marshal(req)
+	if err != nil {
+		return nil, toolkit.Errorf("unable to serialize request. %s", err.Error())
+	}
+	httpReq, err := http.NewRequest(http.MethodPost, c.baseURL+"/"+action, bytes.NewReader(bs))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	client := c.client
+	if timeout > 0 {
+		client = &http.Client{Transport: c.client.Transport, Timeout: timeout}
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, toolkit.Errorf("unable to call %s. %s", action, err.Error())
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		res := new(response)
+		if err = json.NewDecoder(resp.Body).Decode(res); err != nil || res.Error == "" {
+			return nil, toolkit.Errorf("%s fail. %s", action, resp.Status)
+		}
+		return nil, toolkit.Error(res.Error)
+	}
+	return resp, nil
+}
+
+// call sends a request and reads its response
+func (c *Connection) call(action string, req *request, res *response) error {
+	resp, err := c.post(action, req, DefaultTimeout)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	decoder := json.NewDecoder(resp.Body)
+	decoder.UseNumber()
+	if err = decoder.Decode(res); err != nil {
+		return toolkit.Errorf("invalid response of %s. %s", action, err.Error())
+	}
+	if res.Error != "" {
+		return toolkit.Error(res.Error)
+	}
+	res.Result = decode(res.Result)
+	return nil
+}
@@ -0,0 +1,174 @@
+package remote
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+
+	"github.com/eaciit/toolkit"
+
+	"github.com/eaciit/dbflex"
+)
+
+// MaxDocumentSize is maximum size in bytes of a streamed document
+var MaxDocumentSize = 16 * 1024 * 1024
+
+// Cursor of remote driver. Documents are streamed by the server and read as they are fetched,
+// reset sends the command again
+type Cursor struct {
+	dbflex.CursorBase
+
+	conn *Connection
+	req  *request
+
+	body    io.ReadCloser
+	scanner *bufio.Scanner
+	eof     bool
+}
+
+func (c *Cursor) open() error {
+	c.Close()
+	resp, err := c.conn.post("cursor", c.req, 0)
+	if err != nil {
+		return err
+	}
+
+	c.body = resp.Body
+	c.scanner = bufio.NewScanner(resp.Body)
+	c.scanner.Buffer(make([]byte, 64*1024), MaxDocumentSize)
+	c.eof = false
+	return nil
+}
+
+func (c *Cursor) Reset() error {
+	if c.conn == nil {
+		return c.Error()
+	}
+	c.SetError(c.open())
+	return c.Error()
+}
+
+// next returns next document of the stream, nil when the stream is ended
+func (c *Cursor) next() (toolkit.M, error) {
+	if c.eof || c.scanner == nil {
+		return nil, nil
+	}
+
+	if !c.scanner.Scan() {
+		c.eof = true
+		if err := c.scanner.Err(); err != nil {
+			return nil, toolkit.Errorf("unable to read cursor. %s", err.Error())
+		}
+		return nil, toolkit.Errorf("unable to read cursor. stream is ended unexpectedly")
+	}
+
+	fr := new(frame)
+	decoder := json.NewDecoder(bytes.NewReader(c.scanner.Bytes()))
+	decoder.UseNumber()
+	if err := decoder.Decode(fr); err != nil {
+		c.eof = true
+		return nil, toolkit.Errorf("unable to parse cursor data. %s", err.Error())
+	}
+	switch {
+	case fr.Error != "":
+		c.eof = true
+		return nil, toolkit.Error(fr.Error)
+	case fr.EOF:
+		c.eof = true
+		return nil, nil
+	}
+	m, _ := decode(fr.Data).(toolkit.M)
+	return m, nil
+}
+
+func (c *Cursor) Fetch(out interface{}) error {
+	if c.Error() != nil {
+		return c.Error()
+	}
+
+	m, err := c.next()
+	if err != nil {
+		return err
+	}
+	if m == nil {
+		return toolkit.Error("EOF")
+	}
+
+	err = decodeTo(m, out)
+	if c.CloseAfterFetch() {
+		c.Close()
+	}
+	return err
+}
+
+func (c *Cursor) Fetchs(result interface{}, n int) error {
+	if c.Error() != nil {
+		return c.Error()
+	}
+
+	v := reflect.TypeOf(result).Elem().Elem()
+	ivs := reflect.MakeSlice(reflect.SliceOf(v), 0, 0)
+	for read := 0; n == 0 || read < n; read++ {
+		m, err := c.next()
+		if err != nil {
+			return err
+		}
+		if m == nil {
+			break
+		}
+
+		ivp := reflect.New(v)
+		if v.Kind() == reflect.Map {
+			ivp.Elem().Set(reflect.MakeMap(v))
+		}
+		if err = decodeTo(m, ivp.Interface()); err != nil {
+			return toolkit.Errorf("unable to serialize data. %s", err.Error())
+		}
+		ivs = reflect.Append(ivs, ivp.Elem())
+	}
+	reflect.ValueOf(result).Elem().Set(ivs)
+
+	if c.CloseAfterFetch() {
+		c.Close()
+	}
+	return nil
+}
+
+// Count asks the server to count the command, the stream being fetched is not affected
+func (c *Cursor) Count() int {
+	if c.conn == nil {
+		return 0
+	}
+
+	res := new(response)
+	if err := c.conn.call("count", c.req, res); err != nil {
+		c.SetError(err)
+		return 0
+	}
+	return res.Count
+}
+
+// Close stops the stream, server stops reading its cursor when the client goes away
+func (c *Cursor) Close() {
+	if c.body != nil {
+		c.body.Close()
+		c.body = nil
+		c.scanner = nil
+	}
+	c.eof = true
+}
+
+// decodeTo assigns a document to map output, other output is deserialized using json
+func decodeTo(m toolkit.M, out interface{}) error {
+	switch o := out.(type) {
+	case *toolkit.M:
+		*o = m
+		return nil
+	case *map[string]interface{}:
+		*o = m
+		return nil
+	}
+	return toolkit.Serde(m, out, "json")
+}
@@ -0,0 +1,62 @@
+package remote
+
+import (
+	"github.com/eaciit/toolkit"
+
+	"github.com/eaciit/dbflex"
+)
+
+type Query struct {
+	dbflex.QueryBase
+}
+
+// BuildFilter returns the filter as is, it is sent to the server as part of the command
+func (q *Query) BuildFilter(f *dbflex.Filter) (interface{}, error) {
+	return f, nil
+}
+
+// BuildCommand writes query items to be sent to the server
+func (q *Query) BuildCommand() (interface{}, error) {
+	groups := q.Config(dbflex.ConfigKeyGroupedQueryItems, dbflex.GroupedQueryItems{}).(dbflex.GroupedQueryItems)
+	return encodeItems(groups, q.Connection().FieldNameTag())
+}
+
+func (q *Query) items() []*item {
+	items, _ := q.Config(dbflex.ConfigKeyCommand, []*item{}).([]*item)
+	return items
+}
+
+func (q *Query) connection() *Connection {
+	return q.Connection().(*Connection)
+}
+
+func (q *Query) Cursor(parm toolkit.M) dbflex.ICursor {
+	c := new(Cursor)
+	c.SetThis(c)
+	c.SetConnection(q.Connection())
+	c.conn = q.connection()
+	c.req = &request{Command: q.items(), Parm: encodeParm(parm, c.conn.FieldNameTag())}
+	c.SetError(c.open())
+	return c
+}
+
+// Execute sends the command to the server, result is returned as it is returned by the server driver
+// except result of sql driver which is returned as a document of LastInsertId and RowsAffected
+func (q *Query) Execute(parm toolkit.M) (interface{}, error) {
+	conn := q.connection()
+	res := new(response)
+	req := &request{Command: q.items(), Parm: encodeParm(parm, conn.FieldNameTag())}
+	if err := conn.call("execute", req, res); err != nil {
+		return nil, err
+	}
+	return res.Result, nil
+}
+
+// encodeParm writes parameter of a command, struct data is named by field name tag of the server connection
+func encodeParm(parm toolkit.M, tag string) toolkit.M {
+	if parm == nil {
+		return nil
+	}
+	m, _ := encode(parm, tag).(toolkit.M)
+	return m
+}
@@ -0,0 +1,221 @@
+package remote
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eaciit/toolkit"
+
+	"github.com/eaciit/dbflex"
+	_ "github.com/eaciit/dbflex/drivers/memory"
+	_ "github.com/eaciit/dbflex/drivers/sqlite"
+	"github.com/eaciit/dbflex/orm"
+	"github.com/eaciit/dbflex/testbase"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const token = "secret"
+
+// newServer runs a server in process that exposes a memory database as name
+func newServer(t *testing.T, name string) (*httptest.Server, *Server) {
+	conn, err := dbflex.NewConnectionFromUri("memory://localhost/"+name, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = conn.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	server := NewServer(token).Register(name, conn)
+	return httptest.NewServer(server), server
+}
+
+func connTxt(ts *httptest.Server, name, token string) string {
+	return toolkit.Sprintf("remote://%s/%s?token=%s", strings.TrimPrefix(ts.URL, "http://"), name, token)
+}
+
+func TestCRUD(t *testing.T) {
+	ts, _ := newServer(t, "crud")
+	defer ts.Close()
+
+	crud := testbase.NewCRUD(t, connTxt(ts, "crud", token), 1000, nil)
+	crud.RunTest()
+}
+
+type record struct {
+	orm.DatamodelBase `json:"-"`
+	ID                string `json:"_id"`
+	Name              string
+	Qty               int
+	Created           time.Time
+}
+
+func (i *record) TableName() string {
+	return "items"
+}
+
+func (i *record) Id() ([]string, []interface{}) {
+	return []string{"ID"}, []interface{}{i.ID}
+}
+
+func TestCommands(t *testing.T) {
+	ts, server := newServer(t, "commands")
+	defer ts.Close()
+	server.SetBatchSize(2)
+
+	Convey("Remote commands", t, func() {
+		Convey("Token and name are checked", func() {
+			conn, err := dbflex.NewConnectionFromUri(connTxt(ts, "commands", "wrong"), nil)
+			So(err, ShouldBeNil)
+			So(conn.Connect(), ShouldNotBeNil)
+
+			conn, _ = dbflex.NewConnectionFromUri(connTxt(ts, "unknown", token), nil)
+			So(conn.Connect(), ShouldNotBeNil)
+
+			memconn, _ := server.connection("commands")
+			open := NewServer("").Register("commands", memconn)
+			tsOpen := httptest.NewServer(open)
+			defer tsOpen.Close()
+			conn, _ = dbflex.NewConnectionFromUri(connTxt(tsOpen, "commands", ""), nil)
+			So(conn.Connect(), ShouldNotBeNil)
+			open.SetInsecure(true)
+			So(conn.Connect(), ShouldBeNil)
+			conn.Close()
+		})
+
+		Convey("Request body is limited", func() {
+			req, _ := http.NewRequest(http.MethodPost, ts.URL+"/commands/connect",
+				strings.NewReader(`{"Table":"`+strings.Repeat("x", int(MaxRequestSize))+`"}`))
+			req.Header.Set("Authorization", "Bearer "+token)
+			res, err := http.DefaultClient.Do(req)
+			So(err, ShouldBeNil)
+			res.Body.Close()
+			So(res.StatusCode, ShouldEqual, http.StatusBadRequest)
+		})
+
+		conn, err := dbflex.NewConnectionFromUri(connTxt(ts, "commands", token), nil)
+		So(err, ShouldBeNil)
+		So(conn.Connect(), ShouldBeNil)
+		So(conn.FieldNameTag(), ShouldEqual, "json")
+		defer conn.Close()
+		defer conn.DropTable("items")
+
+		now := time.Now()
+		for i := 1; i <= 5; i++ {
+			_, err = conn.Execute(dbflex.From("items").Insert(), toolkit.M{}.Set("data",
+				&record{ID: toolkit.Sprintf("I%d", i), Name: toolkit.Sprintf("Item %d", i), Qty: i * 10, Created: now.AddDate(0, 0, -i)}))
+			So(err, ShouldBeNil)
+		}
+
+		Convey("Prepare is checked by the server", func() {
+			_, err := conn.Prepare(dbflex.From("items").Where(dbflex.Eq("qty", 1)))
+			So(err, ShouldBeNil)
+
+			memconn, _ := server.connection("commands")
+			server.Unregister("commands")
+			defer server.Register("commands", memconn)
+			_, err = conn.Prepare(dbflex.From("items").Where(dbflex.Eq("qty", 1)))
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Values keep their type", func() {
+			ms := []toolkit.M{}
+			cur := conn.Cursor(dbflex.From("items").Select().Where(dbflex.Lt("created", now.AddDate(0, 0, -3))).OrderBy("_id"), nil)
+			So(cur.Fetchs(&ms, 0), ShouldBeNil)
+			So(len(ms), ShouldEqual, 2)
+			So(ms[0].Get("Qty"), ShouldEqual, 40)
+			So(ms[0].Get("Created"), ShouldHaveSameTypeAs, time.Time{})
+			So(ms[0].Get("Created").(time.Time).Equal(now.AddDate(0, 0, -4)), ShouldBeTrue)
+		})
+
+		Convey("Cursor is streamed", func() {
+			cur := conn.Cursor(dbflex.From("items").Select().Where(dbflex.Contains("name", "Item")).OrderBy("-qty"), nil)
+			So(cur.Count(), ShouldEqual, 5)
+
+			items := []record{}
+			So(cur.Fetchs(&items, 3), ShouldBeNil)
+			So(len(items), ShouldEqual, 3)
+			So(items[0].ID, ShouldEqual, "I5")
+			So(cur.Fetchs(&items, 0), ShouldBeNil)
+			So(len(items), ShouldEqual, 2)
+			So(cur.Fetch(new(record)), ShouldNotBeNil)
+
+			So(cur.Reset(), ShouldBeNil)
+			it := new(record)
+			So(cur.Fetch(it), ShouldBeNil)
+			So(it.ID, ShouldEqual, "I5")
+			cur.Close()
+		})
+
+		Convey("Update, delete and orm", func() {
+			n, err := conn.Execute(dbflex.From("items").Where(dbflex.Gte("qty", 40)).Modify(dbflex.Inc("qty", 1)), nil)
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 2)
+
+			it := &record{ID: "I5"}
+			So(orm.Get(conn, it), ShouldBeNil)
+			So(it.Qty, ShouldEqual, 51)
+			it.Name = "Renamed"
+			So(orm.Save(conn, it), ShouldBeNil)
+
+			got := &record{ID: "I5"}
+			So(orm.Get(conn, got), ShouldBeNil)
+			So(got.Name, ShouldEqual, "Renamed")
+
+			So(orm.Delete(conn, got), ShouldBeNil)
+			So(conn.Cursor(dbflex.From("items").Select(), nil).Count(), ShouldEqual, 4)
+		})
+
+		Convey("Object names", func() {
+			So(conn.ObjectNames(dbflex.ObjTypeTable), ShouldContain, "items")
+		})
+	})
+}
+
+type grade struct {
+	ID   string `sqlname:"id"`
+	Name string
+}
+
+func (g *grade) TableName() string {
+	return "grades"
+}
+
+func TestSqlite(t *testing.T) {
+	workpath, _ := ioutil.TempDir("", "dbflexremote")
+	defer os.RemoveAll(workpath)
+
+	Convey("Cursor of sql database is streamed in batches", t, func() {
+		conn, err := dbflex.NewConnectionFromUri(
+			toolkit.Sprintf("sqlite://localhost/%s", filepath.Join(workpath, "remote.db")), nil)
+		So(err, ShouldBeNil)
+		So(conn.Connect(), ShouldBeNil)
+		defer conn.Close()
+		So(conn.ValidateTable(new(grade), false), ShouldBeNil)
+		for i := 1; i <= 5; i++ {
+			_, err = conn.Execute(dbflex.From("grades").Insert(), toolkit.M{}.Set("data",
+				&grade{toolkit.Sprintf("G%d", i), toolkit.Sprintf("Grade %d", i)}))
+			So(err, ShouldBeNil)
+		}
+
+		server := NewServer(token).Register("sql", conn).SetBatchSize(2)
+		ts := httptest.NewServer(server)
+		defer ts.Close()
+
+		remote, err := dbflex.NewConnectionFromUri(connTxt(ts, "sql", token), nil)
+		So(err, ShouldBeNil)
+		So(remote.Connect(), ShouldBeNil)
+		defer remote.Close()
+
+		grades := []grade{}
+		So(remote.Cursor(dbflex.From("grades").Select().OrderBy("id"), nil).Fetchs(&grades, 0), ShouldBeNil)
+		So(len(grades), ShouldEqual, 5)
+		So(grades[4].ID, ShouldEqual, "G5")
+	})
+}
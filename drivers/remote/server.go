@@ -0,0 +1,250 @@
+package remote
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/eaciit/toolkit"
+
+	"github.com/eaciit/dbflex"
+)
+
+// DefaultBatchSize is number of documents read from a cursor before they are flushed to the client
+var DefaultBatchSize = 100
+
+// MaxRequestSize is the maximum size in bytes of a request body
+var MaxRequestSize int64 = 10 << 20
+
+// Server exposes connections over HTTP. Each connection is registered by name and served on /<name>/<action>,
+// every request should carry the token as bearer authorization
+type Server struct {
+	sync.RWMutex
+
+	token     string
+	insecure  bool
+	batchSize int
+	conns     map[string]dbflex.IConnection
+}
+
+// NewServer creates a server. Server with empty token rejects every request unless it is set insecure
+func NewServer(token string) *Server {
+	s := new(Server)
+	s.token = token
+	s.batchSize = DefaultBatchSize
+	s.conns = map[string]dbflex.IConnection{}
+	return s
+}
+
+// SetInsecure lets a server with empty token accept any request, it should only be used on a trusted network
+func (s *Server) SetInsecure(insecure bool) *Server {
+	s.insecure = insecure
+	return s
+}
+
+// SetBatchSize sets number of documents per flush of a streamed cursor
+func (s *Server) SetBatchSize(n int) *Server {
+	if n > 0 {
+		s.batchSize = n
+	}
+	return s
+}
+
+// Register exposes an established connection under a name
+func (s *Server) Register(name string, conn dbflex.IConnection) *Server {
+	s.Lock()
+	defer s.Unlock()
+	s.conns[name] = conn
+	return s
+}
+
+// Unregister removes a connection, the connection itself is not closed
+func (s *Server) Unregister(name string) {
+	s.Lock()
+	defer s.Unlock()
+	delete(s.conns, name)
+}
+
+func (s *Server) connection(name string) (dbflex.IConnection, bool) {
+	s.RLock()
+	defer s.RUnlock()
+	conn, ok := s.conns[name]
+	return conn, ok
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeResponse(w, http.StatusMethodNotAllowed, &response{Error: "only POST method is allowed"})
+		return
+	}
+
+	if !s.authorized(r) {
+		writeResponse(w, http.StatusUnauthorized, &response{Error: "invalid token"})
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) != 2 {
+		writeResponse(w, http.StatusNotFound, &response{Error: "path should be /<name>/<action>"})
+		return
+	}
+	conn, ok := s.connection(parts[0])
+	if !ok {
+		writeResponse(w, http.StatusNotFound, &response{Error: toolkit.Sprintf("connection %s is not found", parts[0])})
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, MaxRequestSize)
+	req := new(request)
+	if err := unmarshalReader(r, req); err != nil {
+		writeResponse(w, http.StatusBadRequest, &response{Error: toolkit.Sprintf("invalid request. %s", err.Error())})
+		return
+	}
+	parm, _ := decode(req.Parm).(toolkit.M)
+
+	switch parts[1] {
+	case "connect":
+		writeResponse(w, http.StatusOK, &response{FieldNameTag: conn.FieldNameTag()})
+
+	case "objectnames":
+		writeResponse(w, http.StatusOK, &response{Names: conn.ObjectNames(dbflex.ObjTypeEnum(req.ObjType))})
+
+	case "droptable":
+		if err := conn.DropTable(req.Table); err != nil {
+			writeResponse(w, http.StatusInternalServerError, &response{Error: err.Error()})
+			return
+		}
+		writeResponse(w, http.StatusOK, &response{})
+
+	case "prepare", "execute", "cursor", "count":
+		cmd, err := decodeCommand(req.Command)
+		if err != nil {
+			writeResponse(w, http.StatusBadRequest, &response{Error: err.Error()})
+			return
+		}
+		s.serveCommand(w, r, parts[1], conn, cmd, parm)
+
+	default:
+		writeResponse(w, http.StatusNotFound, &response{Error: toolkit.Sprintf("unknown action %s", parts[1])})
+	}
+}
+
+// authorized checks bearer token of a request, server without token only accepts request when it is insecure
+func (s *Server) authorized(r *http.Request) bool {
+	if s.token == "" {
+		return s.insecure
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+func (s *Server) serveCommand(w http.ResponseWriter, r *http.Request, action string, conn dbflex.IConnection,
+	cmd dbflex.ICommand, parm toolkit.M) {
+	switch action {
+	case "prepare":
+		if _, err := conn.Prepare(cmd); err != nil {
+			writeResponse(w, http.StatusBadRequest, &response{Error: err.Error()})
+			return
+		}
+		writeResponse(w, http.StatusOK, &response{})
+
+	case "execute":
+		result, err := conn.Execute(cmd, parm)
+		if err != nil {
+			writeResponse(w, http.StatusInternalServerError, &response{Error: err.Error()})
+			return
+		}
+		writeResponse(w, http.StatusOK, &response{Result: encodeResult(result, conn.FieldNameTag())})
+
+	case "count":
+		cursor := conn.Cursor(cmd, parm)
+		defer cursor.Close()
+		n := cursor.Count()
+		if err := cursor.Error(); err != nil {
+			writeResponse(w, http.StatusInternalServerError, &response{Error: err.Error()})
+			return
+		}
+		writeResponse(w, http.StatusOK, &response{Count: n})
+
+	case "cursor":
+		cursor := conn.Cursor(cmd, parm)
+		defer cursor.Close()
+		if err := cursor.Error(); err != nil {
+			writeResponse(w, http.StatusInternalServerError, &response{Error: err.Error()})
+			return
+		}
+		s.stream(w, r, cursor, conn.FieldNameTag())
+	}
+}
+
+// stream writes documents of a cursor as json lines, a batch is flushed as soon as it is read
+// so the client can fetch while the cursor is still being read. The stream stops when client goes away
+func (s *Server) stream(w http.ResponseWriter, r *http.Request, cursor dbflex.ICursor, tag string) {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	bw := bufio.NewWriter(w)
+	encoder := json.NewEncoder(bw)
+	flush := func() {
+		bw.Flush()
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+	}
+	defer flush()
+
+	for {
+		if r.Context().Err() != nil {
+			return
+		}
+
+		docs := []toolkit.M{}
+		if err := cursor.Fetchs(&docs, s.batchSize); err != nil {
+			encoder.Encode(&frame{Error: err.Error()})
+			return
+		}
+		for _, doc := range docs {
+			if err := encoder.Encode(&frame{Data: encode(doc, tag).(toolkit.M)}); err != nil {
+				return
+			}
+		}
+		if len(docs) < s.batchSize {
+			encoder.Encode(&frame{EOF: true})
+			return
+		}
+		flush()
+	}
+}
+
+// encodeResult writes result of execute. Result of sql driver is an interface, it is sent as its values
+func encodeResult(result interface{}, tag string) interface{} {
+	if sqlResult, ok := result.(interface {
+		LastInsertId() (int64, error)
+		RowsAffected() (int64, error)
+	}); ok {
+		m := toolkit.M{}
+		if id, err := sqlResult.LastInsertId(); err == nil {
+			m.Set("LastInsertId", id)
+		}
+		if n, err := sqlResult.RowsAffected(); err == nil {
+			m.Set("RowsAffected", n)
+		}
+		return m
+	}
+	return encode(result, tag)
+}
+
+func unmarshalReader(r *http.Request, out interface{}) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	return decoder.Decode(out)
+}
+
+func writeResponse(w http.ResponseWriter, status int, res *response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(res)
+}
@@ -0,0 +1,317 @@
+package remote
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/eaciit/toolkit"
+
+	"github.com/eaciit/dbflex"
+)
+
+// dateKey marks a time value on the wire, json has no time type so it would arrive as a string
+const dateKey = "$date"
+
+// itemOrder is the order query items are sent. Grouped query items have no order,
+// so a command is rebuilt by the server the same way regardless of how it was written
+var itemOrder = []string{
+	dbflex.QuerySQL, dbflex.QueryFrom, dbflex.QueryWhere, dbflex.QuerySelect, dbflex.QueryAggr,
+	dbflex.QueryGroup, dbflex.QueryOrder, dbflex.QueryInsert, dbflex.QueryUpdate, dbflex.QueryModify,
+	dbflex.QueryDelete, dbflex.QuerySave, dbflex.QueryTake, dbflex.QuerySkip, dbflex.QueryCommand,
+}
+
+// request is body of every call to the server
+type request struct {
+	Command []*item   `json:"command,omitempty"`
+	Parm    toolkit.M `json:"parm,omitempty"`
+	ObjType string    `json:"objtype,omitempty"`
+	Table   string    `json:"table,omitempty"`
+}
+
+// response is body of a call that is not streamed
+type response struct {
+	Error        string      `json:"error,omitempty"`
+	Result       interface{} `json:"result,omitempty"`
+	Count        int         `json:"count,omitempty"`
+	Names        []string    `json:"names,omitempty"`
+	FieldNameTag string      `json:"fieldnametag,omitempty"`
+}
+
+// frame is a line of a streamed cursor. The last frame is either an error or eof
+type frame struct {
+	Data  toolkit.M `json:"data,omitempty"`
+	Error string    `json:"error,omitempty"`
+	EOF   bool      `json:"eof,omitempty"`
+}
+
+// item is a query item with its value written as json, the value is parsed by its op
+type item struct {
+	Op    string          `json:"op"`
+	Value json.RawMessage `json:"value"`
+}
+
+// encodeItems writes grouped query items of a prepared query
+func encodeItems(groups dbflex.GroupedQueryItems, tag string) ([]*item, error) {
+	items := []*item{}
+	for _, op := range itemOrder {
+		for _, qi := range groups[op] {
+			bs, err := json.Marshal(encode(qi.Value, tag))
+			if err != nil {
+				return nil, toolkit.Errorf("unable to serialize %s. %s", op, err.Error())
+			}
+			items = append(items, &item{op, bs})
+		}
+	}
+	return items, nil
+}
+
+// decodeCommand rebuilds a command from its items
+func decodeCommand(items []*item) (dbflex.ICommand, error) {
+	cmd := new(dbflex.CommandBase)
+	for _, it := range items {
+		var err error
+		switch it.Op {
+		case dbflex.QuerySQL:
+			var sql string
+			if err = json.Unmarshal(it.Value, &sql); err == nil {
+				cmd.SQL(sql)
+			}
+
+		case dbflex.QueryFrom:
+			var name string
+			if err = json.Unmarshal(it.Value, &name); err == nil {
+				cmd.From(name)
+			}
+
+		case dbflex.QueryWhere:
+			f := new(dbflex.Filter)
+			if err = unmarshal(it.Value, f); err == nil {
+				cmd.Where(decodeFilter(f))
+			}
+
+		case dbflex.QuerySelect, dbflex.QueryGroup, dbflex.QueryOrder, dbflex.QueryInsert, dbflex.QueryUpdate:
+			fields := []string{}
+			if err = json.Unmarshal(it.Value, &fields); err == nil {
+				switch it.Op {
+				case dbflex.QuerySelect:
+					cmd.Select(fields...)
+				case dbflex.QueryGroup:
+					cmd.GroupBy(fields...)
+				case dbflex.QueryOrder:
+					cmd.OrderBy(fields...)
+				case dbflex.QueryInsert:
+					cmd.Insert(fields...)
+				default:
+					cmd.Update(fields...)
+				}
+			}
+
+		case dbflex.QueryAggr:
+			aggrs := []*dbflex.AggrItem{}
+			if err = json.Unmarshal(it.Value, &aggrs); err == nil {
+				cmd.Aggr(aggrs...)
+			}
+
+		case dbflex.QueryModify:
+			updates := []*dbflex.UpdateItem{}
+			if err = unmarshal(it.Value, &updates); err == nil {
+				for _, u := range updates {
+					u.Value = decode(u.Value)
+				}
+				cmd.Modify(updates...)
+			}
+
+		case dbflex.QueryDelete:
+			cmd.Delete()
+
+		case dbflex.QuerySave:
+			cmd.Save()
+
+		case dbflex.QueryTake, dbflex.QuerySkip:
+			var n int
+			if err = json.Unmarshal(it.Value, &n); err == nil {
+				if it.Op == dbflex.QueryTake {
+					cmd.Take(n)
+				} else {
+					cmd.Skip(n)
+				}
+			}
+
+		case dbflex.QueryCommand:
+			m := toolkit.M{}
+			if err = unmarshal(it.Value, &m); err == nil {
+				m = decode(m).(toolkit.M)
+				cmd.Command(m.GetString("command"), m.Get("data"))
+			}
+
+		default:
+			err = toolkit.Errorf("unknown query item")
+		}
+
+		if err != nil {
+			return nil, toolkit.Errorf("unable to parse %s. %s", it.Op, err.Error())
+		}
+	}
+	return cmd, nil
+}
+
+// decodeFilter restores value of a filter and its items
+func decodeFilter(f *dbflex.Filter) *dbflex.Filter {
+	if f == nil {
+		return nil
+	}
+	for _, child := range f.Items {
+		decodeFilter(child)
+	}
+
+	f.Value = decode(f.Value)
+	if f.Op == dbflex.OpContains {
+		// contains is built from strings, drivers read its value as []string
+		if values, ok := f.Value.([]interface{}); ok {
+			texts := make([]string, len(values))
+			for idx, v := range values {
+				texts[idx] = toolkit.ToString(v)
+			}
+			f.Value = texts
+		}
+	}
+	return f
+}
+
+func unmarshal(bs []byte, out interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(bs))
+	decoder.UseNumber()
+	return decoder.Decode(out)
+}
+
+// encode converts a value into json friendly value. Struct becomes a document named by tag, or by field name
+// if the field has no tag, and time.Time becomes a marked document so it is restored as time by decode
+func encode(v interface{}, tag string) interface{} {
+	return encodeValue(reflect.ValueOf(v), tag)
+}
+
+func encodeValue(rv reflect.Value, tag string) interface{} {
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return nil
+
+	case reflect.Struct:
+		if t, ok := rv.Interface().(time.Time); ok {
+			return toolkit.M{dateKey: t.Format(time.RFC3339Nano)}
+		}
+		m := toolkit.M{}
+		encodeStruct(rv, tag, m)
+		return m
+
+	case reflect.Map:
+		if rv.IsNil() {
+			return nil
+		}
+		m := toolkit.M{}
+		for _, k := range rv.MapKeys() {
+			m[toolkit.ToString(k.Interface())] = encodeValue(rv.MapIndex(k), tag)
+		}
+		return m
+
+	case reflect.Slice:
+		if rv.IsNil() {
+			return nil
+		}
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return rv.Interface()
+		}
+		fallthrough
+
+	case reflect.Array:
+		values := make([]interface{}, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			values[i] = encodeValue(rv.Index(i), tag)
+		}
+		return values
+	}
+
+	return rv.Interface()
+}
+
+// encodeStruct writes exported fields of a struct, embedded struct without tag is flatten
+func encodeStruct(rv reflect.Value, tag string, m toolkit.M) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		fv := rv.Field(i)
+
+		name := field.Name
+		if tagName := strings.Split(field.Tag.Get(tag), ",")[0]; tagName == "-" {
+			continue
+		} else if tagName != "" {
+			name = tagName
+		} else if field.Anonymous {
+			if fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				encodeStruct(fv, tag, m)
+				continue
+			}
+		}
+
+		if !fv.CanInterface() {
+			continue
+		}
+		m[name] = encodeValue(fv, tag)
+	}
+}
+
+// decode restores a value written by encode, whole number is restored as int
+func decode(v interface{}) interface{} {
+	switch tv := v.(type) {
+	case json.Number:
+		if n, err := tv.Int64(); err == nil {
+			return int(n)
+		}
+		f, _ := tv.Float64()
+		return f
+
+	case map[string]interface{}:
+		return decodeDocument(tv)
+
+	case toolkit.M:
+		return decodeDocument(tv)
+
+	case []interface{}:
+		for idx, item := range tv {
+			tv[idx] = decode(item)
+		}
+		return tv
+	}
+	return v
+}
+
+func decodeDocument(m map[string]interface{}) interface{} {
+	if len(m) == 1 {
+		if s, ok := m[dateKey].(string); ok {
+			if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+				return t
+			}
+		}
+	}
+
+	doc := toolkit.M{}
+	for k, v := range m {
+		doc[k] = decode(v)
+	}
+	return doc
+}
@@ -0,0 +1,236 @@
+package sqlite
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/eaciit/dbflex"
+	"github.com/eaciit/toolkit"
+
+	"github.com/eaciit/dbflex/drivers/rdbms"
+	_ "modernc.org/sqlite"
+)
+
+// DefaultBusyTimeout is how long a connection waits for a lock held by other connection.
+// Database is opened in WAL mode so an open cursor does not block writers
+var DefaultBusyTimeout = 5 * time.Second
+
+// Connection implementation of dbflex.IConnection
+type Connection struct {
+	rdbms.Connection
+	db       *sql.DB
+	filePath string
+}
+
+func init() {
+	//=== sample: sqlite://localhost/usr/local/data/app.db or sqlite://data/app.db
+	dbflex.RegisterDriver("sqlite", func(si *dbflex.ServerInfo) dbflex.IConnection {
+		c := new(Connection)
+		c.SetThis(c)
+		c.ServerInfo = *si
+		c.SetFieldNameTag("sqlname")
+		return c
+	})
+}
+
+// Connect opens the database file, it is created if not exist yet
+func (c *Connection) Connect() error {
+	filePath := c.Database
+	if c.Host != "" && c.Host != "localhost" {
+		filePath = filepath.Join(c.Host, c.Database)
+	}
+	if filePath == "" {
+		return toolkit.Errorf("database file is not specified")
+	}
+
+	pragmas := []string{
+		toolkit.Sprintf("_pragma=busy_timeout(%d)", DefaultBusyTimeout/time.Millisecond),
+		"_pragma=journal_mode(WAL)",
+	}
+	for k, v := range c.Config {
+		pragmas = append(pragmas, toolkit.Sprintf("%s=%v", k, v))
+	}
+	db, err := sql.Open("sqlite", "file:"+filePath+"?"+strings.Join(pragmas, "&"))
+	if err != nil {
+		return err
+	}
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return toolkit.Errorf("unable to open %s. %s", filePath, err.Error())
+	}
+
+	c.db = db
+	c.filePath = filePath
+	return nil
+}
+
+func (c *Connection) State() string {
+	if c.db != nil {
+		return dbflex.StateConnected
+	}
+	return dbflex.StateUnknown
+}
+
+// Close database connection
+func (c *Connection) Close() {
+	if c.db != nil {
+		c.db.Close()
+		c.db = nil
+	}
+}
+
+// NewQuery generates new query object to perform query action
+func (c *Connection) NewQuery() dbflex.IQuery {
+	q := new(Query)
+	q.SetThis(q)
+	q.SetDB(c.db).SetDialect(new(Dialect))
+	return q
+}
+
+// ObjectNames returns name of tables, views or both based on sqlite_master
+func (c *Connection) ObjectNames(ot dbflex.ObjTypeEnum) []string {
+	names := []string{}
+	if c.db == nil {
+		return names
+	}
+
+	types := []interface{}{string(ot)}
+	if ot == dbflex.ObjTypeAll || ot == "" {
+		types = []interface{}{string(dbflex.ObjTypeTable), string(dbflex.ObjTypeView)}
+	} else if ot != dbflex.ObjTypeTable && ot != dbflex.ObjTypeView {
+		return names
+	}
+
+	rows, err := c.db.Query("SELECT name FROM sqlite_master WHERE type IN (?"+
+		strings.Repeat(",?", len(types)-1)+") AND name NOT LIKE 'sqlite_%' ORDER BY name", types...)
+	if err != nil {
+		return names
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		if rows.Scan(&name) == nil {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// ValidateTable creates table of obj if it does not exist yet. obj should have TableName method,
+// and its columns are taken from struct fields using sqlname tag. Missing columns are added if autoUpdate is true
+func (c *Connection) ValidateTable(obj interface{}, autoUpdate bool) error {
+	if c.db == nil {
+		return toolkit.Error("no valid connection")
+	}
+
+	tn, ok := obj.(interface{ TableName() string })
+	if !ok {
+		return toolkit.Errorf("unable to get table name, %T has no TableName method", obj)
+	}
+	tablename := tn.TableName()
+
+	d := new(Dialect)
+	names, values, err := rdbms.ColumnValues(obj, d)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return toolkit.Errorf("unable to get columns of %T", obj)
+	}
+	keys := rdbms.KeyColumns(obj, names, d)
+
+	existing, err := c.columnNames(tablename)
+	if err != nil {
+		return err
+	}
+
+	if len(existing) == 0 {
+		defs := []string{}
+		for idx, name := range names {
+			defs = append(defs, d.Quote(name)+" "+sqlType(reflect.TypeOf(values[idx])))
+		}
+		if len(keys) > 0 {
+			quoted := make([]string, len(keys))
+			for idx, key := range keys {
+				quoted[idx] = d.Quote(key)
+			}
+			defs = append(defs, "PRIMARY KEY ("+strings.Join(quoted, ",")+")")
+		}
+		cmdtxt := toolkit.Sprintf("CREATE TABLE %s (%s)", d.Quote(tablename), strings.Join(defs, ", "))
+		if _, err = c.db.Exec(cmdtxt); err != nil {
+			return toolkit.Errorf("%s. SQL Command: %s", err.Error(), cmdtxt)
+		}
+		return nil
+	}
+
+	if !autoUpdate {
+		return nil
+	}
+	for idx, name := range names {
+		if _, exist := existing[strings.ToLower(name)]; exist {
+			continue
+		}
+		cmdtxt := toolkit.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", d.Quote(tablename), d.Quote(name),
+			sqlType(reflect.TypeOf(values[idx])))
+		if _, err = c.db.Exec(cmdtxt); err != nil {
+			return toolkit.Errorf("%s. SQL Command: %s", err.Error(), cmdtxt)
+		}
+	}
+	return nil
+}
+
+// DropTable removes a table if it exists
+func (c *Connection) DropTable(name string) error {
+	if c.db == nil {
+		return toolkit.Error("no valid connection")
+	}
+	cmdtxt := toolkit.Sprintf("DROP TABLE IF EXISTS %s", new(Dialect).Quote(name))
+	if _, err := c.db.Exec(cmdtxt); err != nil {
+		return toolkit.Errorf("%s. SQL Command: %s", err.Error(), cmdtxt)
+	}
+	return nil
+}
+
+// columnNames returns lower case name of columns of a table, it is empty if the table does not exist
+func (c *Connection) columnNames(tablename string) (map[string]string, error) {
+	rows, err := c.db.Query("SELECT name FROM pragma_table_info(?)", tablename)
+	if err != nil {
+		return nil, toolkit.Errorf("unable to get columns of %s. %s", tablename, err.Error())
+	}
+	defer rows.Close()
+
+	names := map[string]string{}
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			return nil, toolkit.Errorf("unable to get columns of %s. %s", tablename, err.Error())
+		}
+		names[strings.ToLower(name)] = name
+	}
+	return names, rows.Err()
+}
+
+func sqlType(t reflect.Type) string {
+	if t == nil {
+		return "TEXT"
+	}
+	if t == reflect.TypeOf(time.Time{}) {
+		return "DATETIME"
+	}
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "INTEGER"
+	case reflect.Float32, reflect.Float64:
+		return "REAL"
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "BLOB"
+		}
+	}
+	return "TEXT"
+}
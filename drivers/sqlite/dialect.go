@@ -0,0 +1,27 @@
+package sqlite
+
+import (
+	"strconv"
+
+	"github.com/eaciit/dbflex/drivers/rdbms"
+)
+
+// Dialect of sqlite, it has no boolean type so boolean is written as 1 or 0
+type Dialect struct {
+	rdbms.BaseDialect
+}
+
+// Paging uses -1 as limit when only skip is set, as sqlite has no OFFSET without LIMIT
+func (d *Dialect) Paging(cmdtxt string, take, skip int) string {
+	if take <= 0 && skip > 0 {
+		return cmdtxt + " LIMIT -1 OFFSET " + strconv.Itoa(skip)
+	}
+	return d.BaseDialect.Paging(cmdtxt, take, skip)
+}
+
+func (d *Dialect) BoolLiteral(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
@@ -0,0 +1,115 @@
+package sqlite
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/eaciit/toolkit"
+
+	"github.com/eaciit/dbflex"
+	"github.com/eaciit/dbflex/testbase"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type employee struct {
+	ID       string `sqlname:"id"`
+	Name     string
+	Grade    int
+	JoinDate time.Time
+	Salary   int
+	Note     string
+}
+
+func (e *employee) TableName() string {
+	return "employees"
+}
+
+type order struct {
+	ID    int `sqlname:"id"`
+	Group string
+}
+
+func (o *order) TableName() string {
+	return "order"
+}
+
+func TestCRUD(t *testing.T) {
+	workpath, _ := ioutil.TempDir("", "dbflexsqlite")
+	defer os.RemoveAll(workpath)
+	connTxt := toolkit.Sprintf("sqlite://localhost/%s", filepath.Join(workpath, "test.db"))
+
+	conn, err := dbflex.NewConnectionFromUri(connTxt, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = conn.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	if err = conn.ValidateTable(new(employee), false); err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	crud := testbase.NewCRUD(t, connTxt, 1000, nil)
+	crud.Set("deletefilter", dbflex.Eq("id", "EMP-10"))
+	crud.RunTest()
+}
+
+func TestTable(t *testing.T) {
+	Convey("Manage table", t, func() {
+		workpath, _ := ioutil.TempDir("", "dbflexsqlite")
+		defer os.RemoveAll(workpath)
+
+		conn, err := dbflex.NewConnectionFromUri(
+			toolkit.Sprintf("sqlite://localhost/%s", filepath.Join(workpath, "test.db")), nil)
+		So(err, ShouldBeNil)
+		So(conn.Connect(), ShouldBeNil)
+		defer conn.Close()
+
+		So(conn.ValidateTable(new(employee), false), ShouldBeNil)
+		So(conn.ObjectNames(dbflex.ObjTypeTable), ShouldResemble, []string{"employees"})
+
+		Convey("Validate existing table keeps its data", func() {
+			_, err := conn.Execute(dbflex.From("employees").Insert(), toolkit.M{}.Set("data",
+				&employee{ID: "E1", Name: "Ann", Grade: 2, JoinDate: time.Now(), Salary: 100}))
+			So(err, ShouldBeNil)
+			So(conn.ValidateTable(new(employee), true), ShouldBeNil)
+
+			e := new(employee)
+			err = conn.Cursor(dbflex.From("employees").Select().Where(dbflex.Eq("id", "E1")), nil).Fetch(e)
+			So(err, ShouldBeNil)
+			So(e.Name, ShouldEqual, "Ann")
+			So(e.Salary, ShouldEqual, 100)
+		})
+
+		Convey("Modify data", func() {
+			_, err := conn.Execute(dbflex.From("employees").Insert(), toolkit.M{}.Set("data",
+				&employee{ID: "E2", Name: "Bob", Grade: 3, JoinDate: time.Now(), Salary: 200}))
+			So(err, ShouldBeNil)
+			_, err = conn.Execute(dbflex.From("employees").Where(dbflex.Eq("id", "E2")).
+				Modify(dbflex.Inc("salary", 50), dbflex.Set("note", "raised")), nil)
+			So(err, ShouldBeNil)
+
+			e := new(employee)
+			err = conn.Cursor(dbflex.From("employees").Select().Where(dbflex.Eq("id", "E2")), nil).Fetch(e)
+			So(err, ShouldBeNil)
+			So(e.Salary, ShouldEqual, 250)
+			So(e.Note, ShouldEqual, "raised")
+		})
+
+		Convey("Table and column names are quoted", func() {
+			So(conn.ValidateTable(new(order), true), ShouldBeNil)
+			_, err := conn.Execute(dbflex.From("order").Insert(), toolkit.M{}.Set("data", &order{1, "A"}))
+			So(err, ShouldBeNil)
+			So(conn.DropTable("order"), ShouldBeNil)
+		})
+
+		Convey("Drop table", func() {
+			So(conn.DropTable("employees"), ShouldBeNil)
+			So(len(conn.ObjectNames(dbflex.ObjTypeAll)), ShouldEqual, 0)
+		})
+	})
+}
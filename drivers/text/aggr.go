@@ -0,0 +1,72 @@
+package text
+
+import (
+	"sort"
+
+	"github.com/eaciit/toolkit"
+
+	"github.com/eaciit/dbflex/drivers/internal/docutil"
+)
+
+// aggregate streams matched lines and computes aggregation per group. Result has the same shape
+// with mongodb driver: _id contains group keys, and each group key and aggregation alias is a field.
+// Column is resolved by header, or by its position when file has no header
+func (c *Cursor) aggregate() ([]toolkit.M, error) {
+	aggregator := docutil.NewAggregator(c.aggrs, c.groups)
+	for {
+		record, err := c.scanMatch(c.headers)
+		if err != nil {
+			return nil, err
+		}
+		if record == nil {
+			break
+		}
+
+		m := record.m
+		if m == nil {
+			if m, err = c.parseRecord(record.line, c.headers); err != nil {
+				return nil, err
+			}
+		}
+		aggregator.Add(m)
+	}
+
+	results := aggregator.Result()
+	if len(c.sorts) > 0 {
+		sorts := make([]string, len(c.sorts))
+		for idx, s := range c.sorts {
+			sorts[idx] = docutil.GroupKey(s)
+		}
+		sort.SliceStable(results, func(i, j int) bool {
+			return docutil.CompareDocument(results[i], results[j], sorts) < 0
+		})
+	}
+	return results, nil
+}
+
+// nextAggr returns next aggregation result after skip and take is being applied
+func (c *Cursor) nextAggr() (toolkit.M, bool, error) {
+	if c.aggregated == nil {
+		results, err := c.aggregate()
+		if err != nil {
+			return nil, false, toolkit.Errorf("unable to aggregate data. %s", err.Error())
+		}
+		c.aggregated = results
+	}
+
+	if c.take > 0 && c.fetched >= c.take {
+		return nil, false, nil
+	}
+	for c.skipped < c.skip && len(c.aggregated) > 0 {
+		c.aggregated = c.aggregated[1:]
+		c.skipped++
+	}
+	if len(c.aggregated) == 0 {
+		return nil, false, nil
+	}
+
+	m := c.aggregated[0]
+	c.aggregated = c.aggregated[1:]
+	c.fetched++
+	return m, true, nil
+}
@@ -0,0 +1,126 @@
+package text
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	CompressionNone = ""
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+)
+
+// compressionExtensions is file suffix of each compression, the first one is used to name a new file
+var compressionExtensions = map[string][]string{
+	CompressionGzip: {".gz", ".gzip"},
+	CompressionZstd: {".zst", ".zstd"},
+}
+
+// compressionOf detects compression of a file by its extension
+func compressionOf(filePath string) string {
+	lpath := strings.ToLower(filePath)
+	for compression, exts := range compressionExtensions {
+		for _, ext := range exts {
+			if strings.HasSuffix(lpath, ext) {
+				return compression
+			}
+		}
+	}
+	return CompressionNone
+}
+
+// trimCompressionExtension removes compression suffix of a file name
+func trimCompressionExtension(name string) string {
+	lname := strings.ToLower(name)
+	for _, exts := range compressionExtensions {
+		for _, ext := range exts {
+			if strings.HasSuffix(lname, ext) {
+				return name[:len(name)-len(ext)]
+			}
+		}
+	}
+	return name
+}
+
+type textReader struct {
+	io.Reader
+	closers []func() error
+}
+
+func (r *textReader) Close() error {
+	var err error
+	for _, closer := range r.closers {
+		if e := closer(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// openTextFile opens a file for reading, compressed file is decompressed transparently.
+// Closing the reader also closes the file
+func openTextFile(filePath string) (io.ReadCloser, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := newTextReader(f, compressionOf(filePath))
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	r.closers = append(r.closers, f.Close)
+	return r, nil
+}
+
+func newTextReader(r io.Reader, compression string) (*textReader, error) {
+	switch compression {
+	case CompressionGzip:
+		gr, err := gzip.NewReader(r)
+		if err == io.EOF {
+			//-- empty file has no gzip header
+			return &textReader{Reader: strings.NewReader("")}, nil
+		} else if err != nil {
+			return nil, err
+		}
+		return &textReader{Reader: gr, closers: []func() error{gr.Close}}, nil
+
+	case CompressionZstd:
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return &textReader{Reader: zr, closers: []func() error{func() error {
+			zr.Close()
+			return nil
+		}}}, nil
+	}
+	return &textReader{Reader: r}, nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// newTextWriter wraps w with compressor. Each writer produces a complete compressed stream,
+// so appending into an existing compressed file is still readable as a concatenated stream
+func newTextWriter(w io.Writer, compression string) (io.WriteCloser, error) {
+	switch compression {
+	case CompressionGzip:
+		return gzip.NewWriter(w), nil
+
+	case CompressionZstd:
+		return zstd.NewWriter(w)
+	}
+	return nopWriteCloser{w}, nil
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/eaciit/toolkit"
 
@@ -28,7 +29,17 @@ type Connection struct {
 	dirInfo        os.FileInfo
 	dirPath        string
 	extension      string
+	compression    string
 	textObjSetting *TextObjSetting
+
+	locks      map[string]*sync.RWMutex
+	locksMutex sync.Mutex
+
+	counts      map[string]*countCacheItem
+	countsMutex sync.Mutex
+
+	columns      map[string]*columnCacheItem
+	columnsMutex sync.Mutex
 }
 
 func (c *Connection) Connect() error {
@@ -52,7 +63,14 @@ func (c *Connection) Connect() error {
 	c.dirPath = dirpath
 
 	c.extension = c.Config.Get("extension", "").(string)
+	c.compression = strings.ToLower(c.Config.Get("compression", CompressionNone).(string))
+	if _, ok := compressionExtensions[c.compression]; !ok && c.compression != CompressionNone {
+		return toolkit.Errorf("compression %s is not supported", c.compression)
+	}
 	c.textObjSetting = c.Config.Get("text_obj_setting", NewTextObjSetting(',')).(*TextObjSetting)
+	if c.textObjSetting.FieldNameTag == "" {
+		c.textObjSetting.FieldNameTag = c.FieldNameTag()
+	}
 	return nil
 }
 
@@ -88,6 +106,10 @@ func (c *Connection) ObjectNames(dbflex.ObjTypeEnum) []string {
 	names := []string{}
 	for _, fi := range files {
 		name := strings.ToLower(fi.Name())
+		if isLockFile(name) || isSchemaFile(name) {
+			continue
+		}
+		name = trimCompressionExtension(name)
 		if len(c.extension) == 0 {
 			names = append(names, name)
 		} else {
@@ -103,7 +125,49 @@ func (c *Connection) ValidateTable(interface{}, bool) error {
 	return nil
 }
 
+// DropTable removes file of a table and its lock file. Name could be a file name or a table name
 func (c *Connection) DropTable(name string) error {
-	filepath := filepath.Join(c.dirPath, name)
-	return os.Remove(filepath)
+	filePath := filepath.Join(c.dirPath, name)
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		filePath = c.tableFilePath(name)
+	}
+	if err := os.Remove(filePath); err != nil {
+		return err
+	}
+	if err := os.Remove(filePath + LockFileExtension); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (c *Connection) EnsureIndex(string, *dbflex.Index) error {
+	return toolkit.Errorf("index is not supported by text driver")
+}
+
+func (c *Connection) DropIndex(string, string) error {
+	return toolkit.Errorf("index is not supported by text driver")
+}
+
+func (c *Connection) Indexes(string) ([]*dbflex.Index, error) {
+	return nil, toolkit.Errorf("index is not supported by text driver")
+}
+
+// tableFilePath returns file of a table. If compression is not configured, an existing compressed
+// file of the table is used when the plain file is not exist
+func (c *Connection) tableFilePath(tablename string) string {
+	filePath := filepath.Join(c.dirPath, tablename+"."+c.extension)
+	if c.compression != CompressionNone {
+		return filePath + compressionExtensions[c.compression][0]
+	}
+
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		for _, compression := range []string{CompressionGzip, CompressionZstd} {
+			for _, ext := range compressionExtensions[compression] {
+				if _, err := os.Stat(filePath + ext); err == nil {
+					return filePath + ext
+				}
+			}
+		}
+	}
+	return filePath
 }
@@ -0,0 +1,138 @@
+package text
+
+import (
+	"os"
+	"time"
+
+	"github.com/eaciit/toolkit"
+)
+
+type countCacheItem struct {
+	size    int64
+	modTime time.Time
+	count   int
+}
+
+// useCountCache reads count_cache config. If it is true, number of records of a file is cached
+// and reused as long as size and modification time of the file are not changed
+func (c *Connection) useCountCache() bool {
+	switch v := c.Config.Get("count_cache", false).(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true" || v == "1"
+	}
+	return false
+}
+
+func (c *Connection) cachedCount(filePath string, fi os.FileInfo) (int, bool) {
+	c.countsMutex.Lock()
+	defer c.countsMutex.Unlock()
+
+	item, ok := c.counts[filePath]
+	if !ok || item.size != fi.Size() || !item.modTime.Equal(fi.ModTime()) {
+		return 0, false
+	}
+	return item.count, true
+}
+
+func (c *Connection) setCachedCount(filePath string, fi os.FileInfo, n int) {
+	c.countsMutex.Lock()
+	defer c.countsMutex.Unlock()
+
+	if c.counts == nil {
+		c.counts = map[string]*countCacheItem{}
+	}
+	c.counts[filePath] = &countCacheItem{fi.Size(), fi.ModTime(), n}
+}
+
+func (c *Cursor) count() (int, error) {
+	if len(c.aggrs) > 0 {
+		return c.countAggr()
+	}
+
+	conn := c.Connection().(*Connection)
+	noFilter := c.filter == nil || c.filter.Op == ""
+	useCache := noFilter && conn.useCountCache()
+
+	var fi os.FileInfo
+	if useCache {
+		var err error
+		if fi, err = os.Stat(c.filePath); err != nil {
+			return 0, err
+		}
+		if n, ok := conn.cachedCount(c.filePath, fi); ok {
+			return n, nil
+		}
+	}
+
+	f, err := openTextFile(c.filePath)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	scanner := newLineScanner(f, c.textObjectSetting)
+	headers, err := readHeaders(scanner, c.textObjectSetting)
+	if err != nil {
+		return 0, err
+	}
+	names, cfg, err := conn.tableColumns(c.tableName, c.filePath, headers, c.textObjectSetting)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for scanner.Scan() {
+		if !noFilter {
+			m := toolkit.M{}
+			if err := textToObj(scanner.Text(), &m, cfg, names...); err != nil {
+				return 0, err
+			}
+			if match, err := c.filter.Match(m); err != nil {
+				return 0, err
+			} else if !match {
+				continue
+			}
+		}
+		n++
+	}
+	if err = scanner.Err(); err != nil {
+		return 0, err
+	}
+
+	if useCache {
+		conn.setCachedCount(c.filePath, fi, n)
+	}
+	return n, nil
+}
+
+// countAggr returns number of groups, aggregation is computed by a separate cursor
+func (c *Cursor) countAggr() (int, error) {
+	cc := new(Cursor)
+	cc.SetThis(cc)
+	cc.SetConnection(c.Connection())
+	cc.filePath = c.filePath
+	cc.tableName = c.tableName
+	cc.textObjectSetting = c.textObjectSetting
+	cc.filter = c.filter
+	cc.aggrs = c.aggrs
+	cc.groups = c.groups
+
+	//-- lock is already held by Count, so file is opened without locking
+	f, err := openTextFile(cc.filePath)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	cc.scanner = newLineScanner(f, cc.textObjectSetting)
+	if err = cc.readHeaders(cc.scanner); err != nil {
+		return 0, toolkit.Errorf("unable to read header. %s", err.Error())
+	}
+
+	results, err := cc.aggregate()
+	if err != nil {
+		return 0, err
+	}
+	return len(results), nil
+}
@@ -2,8 +2,9 @@ package text
 
 import (
 	"bufio"
-	"os"
+	"io"
 	"reflect"
+	"strings"
 
 	"github.com/eaciit/toolkit"
 
@@ -13,14 +14,30 @@ import (
 type Cursor struct {
 	dbflex.CursorBase
 
-	f                 *os.File
+	f                 io.ReadCloser
 	filePath          string
+	tableName         string
 	scanner           *bufio.Scanner
 	textObjectSetting *TextObjSetting
+	headers           []string
+
+	filter     *dbflex.Filter
+	sorts      []string
+	fields     []string
+	skip, take int
+	aggrs      []*dbflex.AggrItem
+	groups     []string
+
+	sorted     lineSource
+	aggregated []toolkit.M
+	skipped    int
+	fetched    int
 }
 
 func (c *Cursor) Reset() error {
-	panic("not implemented")
+	c.Close()
+	c.openFile()
+	return c.Error()
 }
 
 func (c *Cursor) Fetch(out interface{}) error {
@@ -31,12 +48,41 @@ func (c *Cursor) Fetch(out interface{}) error {
 		return c.Error()
 	}
 
-	eof := c.scanner.Scan()
-	if !eof {
-		data := c.scanner.Text()
-		return textToObj(data, out, c.textObjectSetting)
+	lock, err := c.lock()
+	if err != nil {
+		return err
 	}
-	return nil
+	defer lock.Unlock()
+
+	if len(c.aggrs) > 0 {
+		m, ok, err := c.nextAggr()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return toolkit.Error("EOF")
+		}
+		err = toolkit.Serde(m, out, "json")
+		if c.CloseAfterFetch() {
+			c.Close()
+		}
+		return err
+	}
+
+	names := c.recordNames(reflect.Indirect(reflect.ValueOf(out)).Type())
+	data, ok, err := c.nextLine(names)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return toolkit.Error("EOF")
+	}
+
+	err = c.readLine(data, out)
+	if c.CloseAfterFetch() {
+		c.Close()
+	}
+	return err
 }
 
 func (c *Cursor) Fetchs(result interface{}, n int) error {
@@ -47,40 +93,85 @@ func (c *Cursor) Fetchs(result interface{}, n int) error {
 		return c.Error()
 	}
 
-	loop := true
+	lock, err := c.lock()
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
 	read := 0
 	v := reflect.TypeOf(result).Elem().Elem()
 	ivs := reflect.MakeSlice(reflect.SliceOf(v), 0, 0)
+	names := c.recordNames(v)
+
+	for n == 0 || read < n {
+		var (
+			data string
+			m    toolkit.M
+			ok   bool
+			err  error
+		)
+		if len(c.aggrs) > 0 {
+			m, ok, err = c.nextAggr()
+		} else {
+			data, ok, err = c.nextLine(names)
+		}
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
 
-	for c.scanner.Scan() && loop {
 		read++
-		data := c.scanner.Text()
-		iv := reflect.New(v).Interface()
-		err := textToObj(data, iv, c.textObjectSetting)
+		ivp := reflect.New(v)
+		if v.Kind() == reflect.Map {
+			ivp.Elem().Set(reflect.MakeMap(v))
+		}
+		iv := ivp.Interface()
+		if m != nil {
+			err = toolkit.Serde(m, iv, "json")
+		} else {
+			err = c.readLine(data, iv)
+		}
 		if err != nil {
 			return toolkit.Errorf("unable to serialize data. %s - %s", data, err.Error())
 		}
 		ivs = reflect.Append(ivs, reflect.ValueOf(iv).Elem())
-		if read == n {
-			loop = false
-		}
 	}
 	reflect.ValueOf(result).Elem().Set(ivs)
 
+	if c.CloseAfterFetch() {
+		c.Close()
+	}
 	return nil
 }
 
+// Count returns number of records that match the filter, skip and take is not applied.
+// It reads the file using its own handle so it does not affect fetching
 func (c *Cursor) Count() int {
-	i := 0
-	reader := bufio.NewScanner(c.f)
-	for reader.Scan() {
-		_ = reader.Text()
-		i++
+	lock, err := c.lock()
+	if err != nil {
+		c.SetError(err)
+		return 0
 	}
-	return i
+	defer lock.Unlock()
+
+	n, err := c.count()
+	if err != nil {
+		c.SetError(toolkit.Errorf("unable to count %s. %s", c.filePath, err.Error()))
+		return 0
+	}
+	return n
 }
 
 func (c *Cursor) Close() {
+	if c.sorted != nil {
+		c.sorted.Close()
+		c.sorted = nil
+	}
+	c.aggregated = nil
+
 	if c.f != nil {
 		c.f.Close()
 
@@ -91,13 +182,169 @@ func (c *Cursor) Close() {
 
 func (c *Cursor) openFile() {
 	c.SetError(nil)
-	f, err := os.Open(c.filePath)
+	c.skipped = 0
+	c.fetched = 0
+
+	lock, err := c.lock()
 	if err != nil {
 		c.SetError(err)
 		return
 	}
+	defer lock.Unlock()
 
-	scanner := bufio.NewScanner(f)
+	f, err := openTextFile(c.filePath)
+	if err != nil {
+		c.SetError(err)
+		return
+	}
+
+	scanner := newLineScanner(f, c.textObjectSetting)
 	c.f = f
 	c.scanner = scanner
+
+	if err = c.readHeaders(scanner); err != nil {
+		c.SetError(toolkit.Errorf("unable to read header. %s", err.Error()))
+	}
+}
+
+// readHeaders reads header of the file, and column names and types of the table
+func (c *Cursor) readHeaders(scanner *bufio.Scanner) error {
+	headers, err := readHeaders(scanner, c.textObjectSetting)
+	if err != nil {
+		return err
+	}
+	c.headers, c.textObjectSetting, err = c.Connection().(*Connection).tableColumns(c.tableName, c.filePath,
+		headers, c.textObjectSetting)
+	return err
+}
+
+// lock acquires shared lock of the file. It is held only while the file is being read by a cursor
+// operation, so an opened cursor does not block writers
+func (c *Cursor) lock() (*fileLock, error) {
+	return c.Connection().(*Connection).lock(c.filePath, false)
+}
+
+// recordNames returns column names used to evaluate filter and sort of a line
+func (c *Cursor) recordNames(vt reflect.Type) []string {
+	if len(c.headers) > 0 {
+		return c.headers
+	}
+	if c.textObjectSetting.FixedWidth {
+		//-- fixed width fields are declared on struct tags
+		c.textObjectSetting = c.textObjectSetting.forType(vt)
+		return c.textObjectSetting.fixedNames()
+	}
+	if vt.Kind() == reflect.Struct {
+		return structFieldNames(vt)
+	}
+	return []string{}
+}
+
+func (c *Cursor) parseRecord(line string, names []string) (toolkit.M, error) {
+	m := toolkit.M{}
+	err := textToObj(line, &m, c.textObjectSetting, names...)
+	return m, err
+}
+
+// scanMatch returns next line from the file that match the filter
+func (c *Cursor) scanMatch(names []string) (*sortRecord, error) {
+	for c.scanner.Scan() {
+		line := c.scanner.Text()
+		if c.filter == nil && len(c.sorts) == 0 {
+			return &sortRecord{line: line}, nil
+		}
+
+		m, err := c.parseRecord(line, names)
+		if err != nil {
+			return nil, err
+		}
+		match, err := c.filter.Match(m)
+		if err != nil {
+			return nil, err
+		}
+		if match {
+			return &sortRecord{line, m}, nil
+		}
+	}
+	return nil, c.scanner.Err()
+}
+
+// nextLine returns next line after filter, sort, skip and take is being applied
+func (c *Cursor) nextLine(names []string) (string, bool, error) {
+	if c.take > 0 && c.fetched >= c.take {
+		return "", false, nil
+	}
+
+	if len(c.sorts) > 0 && c.sorted == nil {
+		sorted, err := sortLines(func() (*sortRecord, error) {
+			return c.scanMatch(names)
+		}, func(line string) (toolkit.M, error) {
+			return c.parseRecord(line, names)
+		}, c.sorts, c.textObjectSetting)
+		if err != nil {
+			return "", false, toolkit.Errorf("unable to sort data. %s", err.Error())
+		}
+		c.sorted = sorted
+	}
+
+	for {
+		var line string
+		if c.sorted != nil {
+			var ok bool
+			var err error
+			if line, ok, err = c.sorted.Next(); err != nil || !ok {
+				return "", false, err
+			}
+		} else {
+			record, err := c.scanMatch(names)
+			if err != nil || record == nil {
+				return "", false, err
+			}
+			line = record.line
+		}
+
+		if c.skipped < c.skip {
+			c.skipped++
+			continue
+		}
+		c.fetched++
+		return line, true, nil
+	}
+}
+
+// readLine deserializes a line into out, and keeps only selected fields if any
+func (c *Cursor) readLine(line string, out interface{}) error {
+	if err := textToObj(line, out, c.textObjectSetting, c.headers...); err != nil {
+		return err
+	}
+	if len(c.fields) == 0 {
+		return nil
+	}
+
+	selected := func(name string) bool {
+		for _, field := range c.fields {
+			if strings.ToLower(field) == strings.ToLower(name) {
+				return true
+			}
+		}
+		return false
+	}
+
+	rv := reflect.Indirect(reflect.ValueOf(out))
+	if rv.Kind() == reflect.Map {
+		for _, k := range rv.MapKeys() {
+			if !selected(k.String()) {
+				rv.SetMapIndex(k, reflect.Value{})
+			}
+		}
+	} else if rv.Kind() == reflect.Struct {
+		for _, name := range structFieldNames(rv.Type()) {
+			f, _ := rv.Type().FieldByName(name)
+			if !selected(name) && !selected(fieldTextName(f, c.textObjectSetting.FieldNameTag)) {
+				fv := rv.FieldByName(name)
+				fv.Set(reflect.Zero(fv.Type()))
+			}
+		}
+	}
+	return nil
 }
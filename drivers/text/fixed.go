@@ -0,0 +1,183 @@
+package text
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/eaciit/toolkit"
+
+	"github.com/eaciit/dbflex"
+)
+
+// FixedWidthTag is struct tag to declare position of a field on fixed width text, in format of
+// "start,length" or "start,length,align". Start is zero based character position
+const FixedWidthTag = "fixed"
+
+type FieldAlign string
+
+const (
+	AlignLeft  FieldAlign = "left"
+	AlignRight            = "right"
+)
+
+// FixedField is a column of fixed width text
+type FixedField struct {
+	Name   string
+	Start  int
+	Length int
+	// Align decides which side is padded on write and trimmed on read. If it is not defined,
+	// number is aligned right and others are aligned left on write, and both sides are trimmed on read
+	Align FieldAlign
+	// Pad is padding character, default to TextObjSetting.PadChar
+	Pad rune
+}
+
+func NewFixedField(name string, start, length int) *FixedField {
+	f := new(FixedField)
+	f.Name = name
+	f.Start = start
+	f.Length = length
+	return f
+}
+
+func (f *FixedField) SetAlign(align FieldAlign) *FixedField {
+	f.Align = align
+	return f
+}
+
+func (f *FixedField) SetPad(pad rune) *FixedField {
+	f.Pad = pad
+	return f
+}
+
+// SetFixedWidth switches the setting into fixed width format. If fields is not defined,
+// it will be read from struct tag of the data
+func (t *TextObjSetting) SetFixedWidth(fields ...*FixedField) *TextObjSetting {
+	t.FixedWidth = true
+	t.Fields = fields
+	return t
+}
+
+func (t *TextObjSetting) SetPadChar(pad rune) *TextObjSetting {
+	t.PadChar = pad
+	return t
+}
+
+// forType returns the setting with fixed width fields taken from struct tags of vt,
+// if fixed width is used and fields are not declared on the setting
+func (t *TextObjSetting) forType(vt reflect.Type) *TextObjSetting {
+	if !t.FixedWidth || len(t.Fields) > 0 || vt.Kind() != reflect.Struct {
+		return t
+	}
+
+	fields := []*FixedField{}
+	for _, name := range structFieldNames(vt) {
+		f, _ := vt.FieldByName(name)
+		parts := strings.Split(f.Tag.Get(FixedWidthTag), ",")
+		if len(parts) < 2 {
+			continue
+		}
+		field := NewFixedField(name, toolkit.ToInt(strings.TrimSpace(parts[0]), toolkit.RoundingAuto),
+			toolkit.ToInt(strings.TrimSpace(parts[1]), toolkit.RoundingAuto))
+		if len(parts) > 2 {
+			field.Align = FieldAlign(strings.ToLower(strings.TrimSpace(parts[2])))
+		}
+		fields = append(fields, field)
+	}
+	sort.SliceStable(fields, func(i, j int) bool {
+		return fields[i].Start < fields[j].Start
+	})
+
+	cfg := *t
+	cfg.Fields = fields
+	return &cfg
+}
+
+func (t *TextObjSetting) fixedNames() []string {
+	names := make([]string, len(t.Fields))
+	for idx, f := range t.Fields {
+		names[idx] = f.Name
+	}
+	return names
+}
+
+func (t *TextObjSetting) padChar(f *FixedField) rune {
+	if f.Pad != 0 {
+		return f.Pad
+	}
+	if t.PadChar != 0 {
+		return t.PadChar
+	}
+	return ' '
+}
+
+// fixedColumns cuts a line into columns by position. It returns trimmed values and raw text of each column
+func fixedColumns(txt string, cfg *TextObjSetting) ([]string, []string) {
+	runes := []rune(txt)
+	values := make([]string, len(cfg.Fields))
+	raws := make([]string, len(cfg.Fields))
+	for idx, f := range cfg.Fields {
+		start, end := f.Start, f.Start+f.Length
+		if start > len(runes) {
+			start = len(runes)
+		}
+		if end > len(runes) {
+			end = len(runes)
+		}
+		raws[idx] = string(runes[start:end])
+
+		pad := string(cfg.padChar(f))
+		switch f.Align {
+		case AlignLeft:
+			values[idx] = strings.TrimRight(raws[idx], pad)
+		case AlignRight:
+			values[idx] = strings.TrimLeft(raws[idx], pad)
+		default:
+			values[idx] = strings.Trim(raws[idx], pad)
+		}
+		if pad != " " {
+			values[idx] = strings.TrimSpace(values[idx])
+		}
+	}
+	return values, raws
+}
+
+// fixedText pads txt into width of the field. v is the original value, used to align number to the right
+func (t *TextObjSetting) fixedText(f *FixedField, txt string, v interface{}) (string, error) {
+	runes := []rune(txt)
+	if len(runes) > f.Length {
+		return "", toolkit.Errorf("value of %s is longer than %d characters: %s", f.Name, f.Length, txt)
+	}
+
+	align := f.Align
+	if align == "" {
+		align = AlignLeft
+		if _, isNumber := dbflex.NumberValue(v); isNumber {
+			align = AlignRight
+		}
+	}
+
+	pad := strings.Repeat(string(t.padChar(f)), f.Length-len(runes))
+	if align == AlignRight {
+		return pad + txt, nil
+	}
+	return txt + pad, nil
+}
+
+// fixedLine places each column text on its position, gap between columns is filled with pad char.
+// If line is given, columns are placed over it so undeclared columns are kept
+func (t *TextObjSetting) fixedLine(line string, txts []string) string {
+	runes := []rune(line)
+	for _, f := range t.Fields {
+		for len(runes) < f.Start+f.Length {
+			runes = append(runes, t.padChar(&FixedField{}))
+		}
+	}
+	for idx, f := range t.Fields {
+		if idx < len(txts) {
+			copy(runes[f.Start:f.Start+f.Length], []rune(txts[idx]))
+		}
+	}
+	return string(runes)
+}
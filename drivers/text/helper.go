@@ -1,8 +1,11 @@
 package text
 
 import (
+	"bufio"
 	"errors"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,6 +17,28 @@ type TextObjSetting struct {
 	UseSign     bool
 	Signs       [][]rune
 	DateFormats map[string]string
+
+	// UseHeader treats first line, after skipped lines, as header. Columns will be mapped to fields by header name
+	UseHeader bool
+	// SkipLines is number of leading lines to be skipped
+	SkipLines int
+	// FieldNameTag is struct tag used to map a header to a field. Default to connection field name tag
+	FieldNameTag string
+
+	// FixedWidth reads and writes columns by its position instead of delimeter
+	FixedWidth bool
+	// Fields is position of each column on fixed width text, if it is empty it is read from struct tags
+	Fields []*FixedField
+	// PadChar is default padding character of fixed width column
+	PadChar rune
+
+	// MaxRecordSize is maximum size in bytes of a record, default to DefaultMaxRecordSize
+	MaxRecordSize int
+	// Strict rejects malformed quoted record instead of reading it as is, error contains its line number
+	Strict bool
+
+	// columnTypes is type of each column keyed by lower case column name, used to read a record into map
+	columnTypes map[string]string
 }
 
 func NewTextObjSetting(delimeter rune) *TextObjSetting {
@@ -38,6 +63,38 @@ func (t *TextObjSetting) SetSign(runes ...rune) *TextObjSetting {
 	return t
 }
 
+func (t *TextObjSetting) SetUseHeader(b bool) *TextObjSetting {
+	t.UseHeader = b
+	return t
+}
+
+func (t *TextObjSetting) SetSkipLines(n int) *TextObjSetting {
+	t.SkipLines = n
+	return t
+}
+
+func (t *TextObjSetting) SetFieldNameTag(tag string) *TextObjSetting {
+	t.FieldNameTag = tag
+	return t
+}
+
+func (t *TextObjSetting) SetMaxRecordSize(n int) *TextObjSetting {
+	t.MaxRecordSize = n
+	return t
+}
+
+func (t *TextObjSetting) SetStrict(b bool) *TextObjSetting {
+	t.Strict = b
+	return t
+}
+
+// withColumnTypes returns a copy of the setting that reads each column into given type
+func (t *TextObjSetting) withColumnTypes(types map[string]string) *TextObjSetting {
+	cfg := *t
+	cfg.columnTypes = types
+	return &cfg
+}
+
 func (t *TextObjSetting) SetDateFormat(key, value string) *TextObjSetting {
 	if t.DateFormats == nil {
 		t.DateFormats = map[string]string{}
@@ -62,80 +119,182 @@ func (t *TextObjSetting) DateFormat(key string) string {
 func textToObj(txt string, out interface{}, cfg *TextObjSetting, headers ...string) error {
 	vt := reflect.Indirect(reflect.ValueOf(out)).Type()
 	//fmt.Println("Kind:", vt.Kind())
-	if len(headers) == 0 && vt.Kind() == reflect.Struct {
-		for i := 0; i < vt.NumField(); i++ {
-			name := vt.FieldByIndex([]int{i}).Name
-			headers = append(headers, name)
+	if cfg.FixedWidth {
+		if cfg = cfg.forType(vt); len(cfg.Fields) == 0 {
+			return errors.New("fixed width fields are not defined")
+		}
+		headers = cfg.fixedNames()
+	}
+	if vt.Kind() == reflect.Struct {
+		if len(headers) == 0 {
+			headers = structFieldNames(vt)
+		} else {
+			headers = headerFieldNames(headers, vt, cfg.FieldNameTag)
 		}
 	}
 
+	for idx, fieldtxt := range splitText(txt, cfg) {
+		fieldname := ""
+		if idx < len(headers) {
+			fieldname = headers[idx]
+		} else {
+			fieldname = toolkit.ToString(idx)
+		}
+		processTxtToObjField(fieldtxt, out, fieldname, cfg)
+	}
+
+	return nil
+}
+
+// splitText splits a line of text into columns, quote sign will be removed
+func splitText(txt string, cfg *TextObjSetting) []string {
+	values, _ := splitColumns(txt, cfg)
+	return values
+}
+
+// splitColumns splits a line of text into columns. It returns unquoted values and raw text of each column
+func splitColumns(txt string, cfg *TextObjSetting) ([]string, []string) {
+	if cfg.FixedWidth {
+		return fixedColumns(txt, cfg)
+	}
+
 	var closeQuote rune
-	var processBufferToObj bool
 
+	fields := []string{}
+	raws := []string{}
 	inQuote := false
-	txtBuff := ""
-	idx := 0
+	buff := []rune{}
+	start := 0
 
-	for _, char := range txt {
+	runes := []rune(txt)
+	for charIdx := 0; charIdx < len(runes); charIdx++ {
+		char := runes[charIdx]
 		addRune := true
-		processBufferToObj = false
 		if cfg.UseSign {
 			if !inQuote {
-				for _, sign := range cfg.Signs {
-					if len(sign) > 0 {
-						if char == sign[0] {
-							inQuote = true
-							if len(sign) == 1 {
-								closeQuote = char
-							} else {
-								closeQuote = sign[1]
-							}
-
-							addRune = false
-							break
-						}
-					}
+				//-- quote sign only opens a quoted field at beginning of the field
+				if _, closeSign, ok := cfg.sign(char); ok && strings.TrimSpace(string(buff)) == "" {
+					inQuote = true
+					closeQuote = closeSign
+					addRune = false
 				}
 			} else if char == closeQuote {
-				inQuote = false
-				addRune = false
+				//-- doubled close quote is an escaped quote
+				if charIdx+1 < len(runes) && runes[charIdx+1] == closeQuote {
+					charIdx++
+				} else {
+					inQuote = false
+					addRune = false
+				}
 			}
 		}
 
 		if char == cfg.Delimeter && !inQuote {
-			addRune = false
-			processBufferToObj = true
+			fields = append(fields, string(buff))
+			raws = append(raws, string(runes[start:charIdx]))
+			buff = buff[:0]
+			start = charIdx + 1
+		} else if addRune {
+			buff = append(buff, char)
+		}
+	}
+	return append(fields, string(buff)), append(raws, string(runes[start:]))
+}
+
+// structFieldNames returns name of struct fields that can be read and written as text column
+func structFieldNames(vt reflect.Type) []string {
+	names := []string{}
+	for i := 0; i < vt.NumField(); i++ {
+		f := vt.Field(i)
+		if f.PkgPath != "" || f.Anonymous {
+			continue
 		}
+		names = append(names, f.Name)
+	}
+	return names
+}
 
-		if addRune {
-			txtBuff += string(char)
+// fieldTextName returns column name of a struct field, tag value is used if it is defined
+func fieldTextName(f reflect.StructField, tag string) string {
+	if tag != "" {
+		tagValue := strings.Split(f.Tag.Get(tag), ",")[0]
+		if tagValue != "" && tagValue != "-" {
+			return tagValue
 		}
+	}
+	return f.Name
+}
 
-		if processBufferToObj {
-			fieldname := ""
-			if idx < len(headers) {
-				fieldname = headers[idx]
-			} else {
-				fieldname = toolkit.ToString(idx)
+// headerFieldNames maps headers into struct field names. Header is compared case insensitive
+// against tag value and field name, unmatched header is returned as is
+func headerFieldNames(headers []string, vt reflect.Type, tag string) []string {
+	names := make([]string, len(headers))
+	for idx, header := range headers {
+		names[idx] = header
+		lheader := strings.ToLower(strings.TrimSpace(header))
+		for _, name := range structFieldNames(vt) {
+			f, _ := vt.FieldByName(name)
+			if strings.ToLower(fieldTextName(f, tag)) == lheader || strings.ToLower(name) == lheader {
+				names[idx] = name
+				break
 			}
-			processTxtToObjField(txtBuff, out, fieldname, cfg)
-			txtBuff = ""
-			idx++
 		}
 	}
+	return names
+}
 
-	//-- process buff if last operation doesnt does it
-	if !processBufferToObj {
-		fieldname := ""
-		if idx < len(headers) {
-			fieldname = headers[idx]
-		} else {
-			fieldname = toolkit.ToString(idx)
+// readHeaders skips leading lines and reads header if header is used
+func readHeaders(scanner *bufio.Scanner, cfg *TextObjSetting) ([]string, error) {
+	for i := 0; i < cfg.SkipLines; i++ {
+		if !scanner.Scan() {
+			return nil, scanner.Err()
 		}
-		processTxtToObjField(txtBuff, out, fieldname, cfg)
 	}
 
-	return nil
+	if cfg.FixedWidth {
+		//-- column name of fixed width text is defined by its fields, header line is skipped
+		if cfg.UseHeader && !scanner.Scan() {
+			return nil, scanner.Err()
+		}
+		if len(cfg.Fields) == 0 {
+			return nil, nil
+		}
+		return cfg.fixedNames(), nil
+	}
+
+	if !cfg.UseHeader {
+		return nil, nil
+	}
+
+	if !scanner.Scan() {
+		return nil, scanner.Err()
+	}
+	headers := splitText(scanner.Text(), cfg)
+	for idx, header := range headers {
+		headers[idx] = strings.TrimSpace(header)
+	}
+	return headers, nil
+}
+
+// headerToText returns header line of given column names
+func headerToText(headers []string, cfg *TextObjSetting) string {
+	if cfg.FixedWidth {
+		txts := make([]string, len(cfg.Fields))
+		for idx, f := range cfg.Fields {
+			name := []rune(f.Name)
+			if len(name) > f.Length {
+				name = name[:f.Length]
+			}
+			txts[idx], _ = cfg.fixedText(&FixedField{Name: f.Name, Length: f.Length, Align: AlignLeft}, string(name), nil)
+		}
+		return cfg.fixedLine("", txts)
+	}
+
+	txts := make([]string, len(headers))
+	for idx, header := range headers {
+		txts[idx] = cfg.quote(header)
+	}
+	return strings.Join(txts, string(cfg.Delimeter))
 }
 
 func processTxtToObjField(txt string, obj interface{}, fieldname string, cfg *TextObjSetting) error {
@@ -146,11 +305,21 @@ func processTxtToObjField(txt string, obj interface{}, fieldname string, cfg *Te
 
 	if rt.Kind() == reflect.Map {
 		keyType := rt.Key()
-		if keyType.Kind() == reflect.String {
+		if typeName, ok := cfg.columnTypes[strings.ToLower(fieldname)]; ok && keyType.Kind() == reflect.String {
+			//--- column type is known, empty value is kept as nil
+			if txt != "" || typeName == "string" {
+				objField = textToInterface(txt, typeName, cfg.DateFormat(fieldname))
+			}
+			if objField == nil {
+				rv.SetMapIndex(reflect.ValueOf(fieldname), reflect.Zero(rt.Elem()))
+			} else {
+				rv.SetMapIndex(reflect.ValueOf(fieldname), reflect.ValueOf(objField))
+			}
+		} else if keyType.Kind() == reflect.String {
 			//--- first convert to time.Time
 			dateFormat := cfg.DateFormat(fieldname)
-			objField = toolkit.String2Date(txt, dateFormat)
-			txtStr := toolkit.Date2String(objField.(time.Time), dateFormat)
+			objField = parseDate(txt, dateFormat)
+			txtStr := formatDate(objField.(time.Time), dateFormat)
 
 			//--- if fails then do number float
 			if txtStr != txt {
@@ -185,7 +354,11 @@ func processTxtToObjField(txt string, obj interface{}, fieldname string, cfg *Te
 					objField = textToInterface(txt, typeName, cfg.DateFormat(fieldname))
 
 					rfv := rv.FieldByIndex([]int{fieldIdx})
-					rfv.Set(reflect.ValueOf(objField))
+					objValue := reflect.ValueOf(objField)
+					if objValue.Type() != rft.Type && objValue.Type().ConvertibleTo(rft.Type) {
+						objValue = objValue.Convert(rft.Type)
+					}
+					rfv.Set(objValue)
 
 					return "OK"
 				}()
@@ -204,20 +377,167 @@ func textToInterface(txt string, typeName string, dateFormat string) interface{}
 	var objField interface{}
 	if typeName == "string" {
 		objField = txt
-	} else if strings.HasPrefix(typeName, "int") && typeName != "interface{}" {
+	} else if (strings.HasPrefix(typeName, "int") || strings.HasPrefix(typeName, "uint")) && typeName != "interface{}" {
 		objField = toolkit.ToInt(txt, toolkit.RoundingAuto)
 	} else if typeName == "float32" {
 		objField = toolkit.ToFloat32(txt, 4, toolkit.RoundingAuto)
 	} else if typeName == "float64" {
 		objField = toolkit.ToFloat64(txt, 4, toolkit.RoundingAuto)
+	} else if typeName == "bool" {
+		objField, _ = strconv.ParseBool(txt)
 	} else if typeName == "time.Time" {
-		objField = toolkit.ToDate(txt, dateFormat)
+		objField = parseDate(txt, dateFormat)
 	} else {
 		objField = ""
 	}
 	return objField
 }
 
-func objToText(data interface{}, cfg *TextObjSetting) (string, error) {
-	return "", nil
+// parseDate parses txt using toolkit date format, RFC3339 is used if format is empty
+func parseDate(txt, dateFormat string) time.Time {
+	if dateFormat == "" {
+		dt, _ := time.Parse(time.RFC3339Nano, txt)
+		return dt
+	}
+	return toolkit.ToDate(txt, dateFormat)
+}
+
+// formatDate formats dt using toolkit date format, RFC3339 is used if format is empty
+func formatDate(dt time.Time, dateFormat string) string {
+	if dateFormat == "" {
+		return dt.Format(time.RFC3339Nano)
+	}
+	return toolkit.Date2String(dt, dateFormat)
+}
+
+// objToText serializes a struct or a map into a line of text. Columns are ordered by headers,
+// if headers is not defined it will follow struct field order or sorted map keys
+func objToText(data interface{}, cfg *TextObjSetting, headers ...string) (string, error) {
+	names, values, dateKeys, err := objTextValues(data, cfg)
+	if err != nil {
+		return "", err
+	}
+
+	if cfg.FixedWidth {
+		if cfg = cfg.forType(reflect.Indirect(reflect.ValueOf(data)).Type()); len(cfg.Fields) == 0 {
+			return "", errors.New("fixed width fields are not defined")
+		}
+		txts := make([]string, len(cfg.Fields))
+		for idx := range cfg.Fields {
+			if txts[idx], err = cfg.columnText(idx, values, dateKeys); err != nil {
+				return "", err
+			}
+		}
+		return cfg.fixedLine("", txts), nil
+	}
+
+	if len(headers) == 0 {
+		headers = names
+	}
+
+	txts := make([]string, len(headers))
+	for idx, header := range headers {
+		lheader := strings.ToLower(header)
+		txts[idx] = cfg.valueToText(values[lheader], dateKeys[lheader])
+	}
+	return strings.Join(txts, string(cfg.Delimeter)), nil
+}
+
+// objTextValues returns column names of data and its values and date format keys, keyed by lower case column name
+func objTextValues(data interface{}, cfg *TextObjSetting) ([]string, map[string]interface{}, map[string]string, error) {
+	rv := reflect.Indirect(reflect.ValueOf(data))
+	if !rv.IsValid() {
+		return nil, nil, nil, errors.New("data is nil")
+	}
+
+	names := []string{}
+	values := map[string]interface{}{}
+	dateKeys := map[string]string{}
+	switch rv.Kind() {
+	case reflect.Struct:
+		for _, name := range structFieldNames(rv.Type()) {
+			f, _ := rv.Type().FieldByName(name)
+			textName := fieldTextName(f, cfg.FieldNameTag)
+			names = append(names, textName)
+			for _, key := range []string{strings.ToLower(name), strings.ToLower(textName)} {
+				values[key] = rv.FieldByName(name).Interface()
+				dateKeys[key] = name
+			}
+		}
+
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, nil, nil, errors.New("data is a map and need to have string as its key")
+		}
+		for _, k := range rv.MapKeys() {
+			names = append(names, k.String())
+			values[strings.ToLower(k.String())] = rv.MapIndex(k).Interface()
+			dateKeys[strings.ToLower(k.String())] = k.String()
+		}
+		sort.Strings(names)
+
+	default:
+		return nil, nil, nil, toolkit.Errorf("unable to serialize %s, data should be a struct or a map", rv.Type().String())
+	}
+	return names, values, dateKeys, nil
+}
+
+func (t *TextObjSetting) valueToText(v interface{}, fieldname string) string {
+	switch vt := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t.quote(vt)
+	case time.Time:
+		return t.quote(formatDate(vt, t.DateFormat(fieldname)))
+	case bool:
+		return strconv.FormatBool(vt)
+	case float32:
+		return strconv.FormatFloat(float64(vt), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(vt, 'f', -1, 64)
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10)
+	case reflect.String:
+		return t.quote(rv.String())
+	}
+	return t.quote(toolkit.JsonString(v))
+}
+
+// columnText returns text of a fixed width column, values and dateKeys are keyed by lower case column name
+func (t *TextObjSetting) columnText(idx int, values map[string]interface{}, dateKeys map[string]string) (string, error) {
+	f := t.Fields[idx]
+	lname := strings.ToLower(f.Name)
+	dateKey := dateKeys[lname]
+	if dateKey == "" {
+		dateKey = f.Name
+	}
+	return t.fixedText(f, t.valueToText(values[lname], dateKey), values[lname])
+}
+
+// joinColumns rebuilds a line from raw text of its columns
+func (t *TextObjSetting) joinColumns(line string, raws []string) string {
+	if t.FixedWidth {
+		return t.fixedLine(line, raws)
+	}
+	return strings.Join(raws, string(t.Delimeter))
+}
+
+// quote wraps txt with the first sign and escapes the close sign by doubling it
+func (t *TextObjSetting) quote(txt string) string {
+	if t.FixedWidth || !t.UseSign || len(t.Signs) == 0 || len(t.Signs[0]) == 0 {
+		return txt
+	}
+	open := t.Signs[0][0]
+	closeSign := open
+	if len(t.Signs[0]) > 1 {
+		closeSign = t.Signs[0][1]
+	}
+	return string(open) + strings.Replace(txt, string(closeSign), string(closeSign)+string(closeSign), -1) + string(closeSign)
 }
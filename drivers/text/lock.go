@@ -0,0 +1,116 @@
+package text
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eaciit/toolkit"
+)
+
+// DefaultLockTimeout is maximum time to wait for a file lock if lock_timeout is not configured
+var DefaultLockTimeout = 30 * time.Second
+
+// LockFileExtension is appended to table file name to create its lock file
+const LockFileExtension = ".lock"
+
+type fileLock struct {
+	mu        *sync.RWMutex
+	f         *os.File
+	exclusive bool
+}
+
+// lockTimeout reads lock_timeout config, it could be a time.Duration, a duration string
+// such as 500ms, or a number of seconds
+func (c *Connection) lockTimeout() time.Duration {
+	switch v := c.Config.Get("lock_timeout", DefaultLockTimeout).(type) {
+	case time.Duration:
+		return v
+	case string:
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+		return time.Duration(toolkit.ToInt(v, toolkit.RoundingAuto)) * time.Second
+	default:
+		return time.Duration(toolkit.ToInt(v, toolkit.RoundingAuto)) * time.Second
+	}
+}
+
+func (c *Connection) fileMutex(filePath string) *sync.RWMutex {
+	c.locksMutex.Lock()
+	defer c.locksMutex.Unlock()
+
+	if c.locks == nil {
+		c.locks = map[string]*sync.RWMutex{}
+	}
+	mu, ok := c.locks[filePath]
+	if !ok {
+		mu = new(sync.RWMutex)
+		c.locks[filePath] = mu
+	}
+	return mu
+}
+
+// lock acquires in-process lock of the file and then advisory lock of its lock file, so it is safe
+// for goroutines sharing the connection and for other processes. Shared lock is used for reading
+// and exclusive lock is used for writing
+func (c *Connection) lock(filePath string, exclusive bool) (*fileLock, error) {
+	l := &fileLock{mu: c.fileMutex(filePath), exclusive: exclusive}
+	if exclusive {
+		l.mu.Lock()
+	} else {
+		l.mu.RLock()
+	}
+
+	f, err := os.OpenFile(filePath+LockFileExtension, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		//-- reader of a read only directory is not able to create lock file
+		if !exclusive {
+			return l, nil
+		}
+		l.Unlock()
+		return nil, toolkit.Errorf("unable to open lock file of %s. %s", filePath, err.Error())
+	}
+
+	timeout := c.lockTimeout()
+	start := time.Now()
+	for {
+		locked, err := lockFile(f, exclusive)
+		if err != nil {
+			f.Close()
+			l.Unlock()
+			return nil, toolkit.Errorf("unable to lock %s. %s", filePath, err.Error())
+		}
+		if locked {
+			break
+		}
+		if time.Since(start) >= timeout {
+			f.Close()
+			l.Unlock()
+			return nil, toolkit.Errorf("unable to lock %s, timeout after %v", filePath, timeout)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	l.f = f
+	return l, nil
+}
+
+func (l *fileLock) Unlock() {
+	if l.f != nil {
+		unlockFile(l.f)
+		l.f.Close()
+		l.f = nil
+	}
+
+	if l.exclusive {
+		l.mu.Unlock()
+	} else {
+		l.mu.RUnlock()
+	}
+}
+
+func isLockFile(name string) bool {
+	return strings.HasSuffix(strings.ToLower(name), LockFileExtension)
+}
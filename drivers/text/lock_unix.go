@@ -0,0 +1,27 @@
+//go:build !windows
+// +build !windows
+
+package text
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile tries to acquire advisory lock without blocking, it returns false if file is locked by others
+func lockFile(f *os.File, exclusive bool) (bool, error) {
+	how := syscall.LOCK_SH
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+
+	err := syscall.Flock(int(f.Fd()), how|syscall.LOCK_NB)
+	if err == syscall.EWOULDBLOCK {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
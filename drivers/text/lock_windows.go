@@ -0,0 +1,29 @@
+//go:build windows
+// +build windows
+
+package text
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockFile tries to acquire lock on first byte of the lock file using LockFileEx without blocking,
+// it returns false if file is locked by others
+func lockFile(f *os.File, exclusive bool) (bool, error) {
+	flags := uint32(windows.LOCKFILE_FAIL_IMMEDIATELY)
+	if exclusive {
+		flags |= windows.LOCKFILE_EXCLUSIVE_LOCK
+	}
+
+	err := windows.LockFileEx(windows.Handle(f.Fd()), flags, 0, 1, 0, new(windows.Overlapped))
+	if err == windows.ERROR_LOCK_VIOLATION {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func unlockFile(f *os.File) error {
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, new(windows.Overlapped))
+}
@@ -2,8 +2,12 @@ package text
 
 import (
 	"bufio"
+	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 
 	"github.com/eaciit/dbflex"
 	"github.com/eaciit/toolkit"
@@ -15,8 +19,9 @@ type Query struct {
 	textObjectSetting *TextObjSetting
 }
 
-func (q *Query) BuildFilter(*dbflex.Filter) (interface{}, error) {
-	return nil, nil
+// BuildFilter returns the filter as is, it is evaluated per line by the cursor
+func (q *Query) BuildFilter(f *dbflex.Filter) (interface{}, error) {
+	return f, nil
 }
 
 func (q *Query) BuildCommand() (interface{}, error) {
@@ -25,16 +30,12 @@ func (q *Query) BuildCommand() (interface{}, error) {
 
 func (q *Query) filePath() (string, error) {
 	conn := q.Connection().(*Connection)
-	filename := ""
 	tablename := q.Config(dbflex.ConfigKeyTableName, "").(string)
 
 	if tablename == "" {
 		return "", toolkit.Errorf("no tablename is specified")
-	} else {
-		filename = tablename + "." + conn.extension
 	}
-	filePath := filepath.Join(conn.dirPath, filename)
-	return filePath, nil
+	return conn.tableFilePath(tablename), nil
 }
 
 func (q *Query) Cursor(toolkit.M) dbflex.ICursor {
@@ -56,8 +57,43 @@ func (q *Query) Cursor(toolkit.M) dbflex.ICursor {
 	}
 
 	c.filePath = filePath
-	c.openFile()
+	c.tableName = q.Config(dbflex.ConfigKeyTableName, "").(string)
 	c.textObjectSetting = q.textObjectSetting
+
+	parts := q.Config(dbflex.ConfigKeyGroupedQueryItems, dbflex.GroupedQueryItems{}).(dbflex.GroupedQueryItems)
+	if filter, ok := q.Config(dbflex.ConfigKeyFilter, nil).(*dbflex.Filter); ok {
+		c.filter = filter
+	}
+	c.fields = q.Config("fields", []string{}).([]string)
+	if items, ok := parts[dbflex.QueryOrder]; ok {
+		for _, item := range items {
+			for _, field := range item.Value.([]string) {
+				if strings.TrimSpace(field) != "" {
+					c.sorts = append(c.sorts, strings.TrimSpace(field))
+				}
+			}
+		}
+	}
+	if items, ok := parts[dbflex.QueryAggr]; ok {
+		c.aggrs = items[0].Value.([]*dbflex.AggrItem)
+	}
+	if items, ok := parts[dbflex.QueryGroup]; ok {
+		for _, item := range items {
+			for _, field := range item.Value.([]string) {
+				if strings.TrimSpace(field) != "" {
+					c.groups = append(c.groups, strings.TrimSpace(field))
+				}
+			}
+		}
+	}
+	if items, ok := parts[dbflex.QuerySkip]; ok {
+		c.skip = items[0].Value.(int)
+	}
+	if items, ok := parts[dbflex.QueryTake]; ok {
+		c.take = items[0].Value.(int)
+	}
+
+	c.openFile()
 	return c
 }
 
@@ -69,48 +105,104 @@ func (q *Query) Execute(parm toolkit.M) (interface{}, error) {
 		return nil, err
 	}
 
-	fileExist := false
-	if _, err = os.Stat(filePath); err == nil {
-		fileExist = true
+	if cmdType == dbflex.QuerySelect {
+		return nil, toolkit.Errorf("select command should use cursor instead of execute")
 	}
 
-	var file *os.File
-	//-- if save, insert and update. create the file
-	if (cmdType == dbflex.QueryInsert ||
-		cmdType == dbflex.QuerySave ||
-		cmdType == dbflex.QueryUpdate ||
-		cmdType == dbflex.QueryDelete) && !fileExist {
-		file, err = os.Create(filePath)
-		if err != nil {
-			return err, toolkit.Errorf("unable to create file %s. %s", filePath, err.Error())
-		}
-	} else {
-		file, err = os.OpenFile(filePath, os.O_APPEND|os.O_RDWR, os.ModeAppend)
-		if err != nil {
-			return err, toolkit.Errorf("unable to open file %s. %s", filePath, err.Error())
-		}
+	lock, err := q.Connection().(*Connection).lock(filePath, true)
+	if err != nil {
+		return nil, err
 	}
-
-	defer func() {
-		file.Close()
-	}()
+	defer lock.Unlock()
 
 	switch cmdType {
-	case dbflex.QuerySelect:
-		return nil, toolkit.Errorf("select command should use cursor instead of execute")
+	case dbflex.QueryUpdate, dbflex.QueryDelete:
+		return q.rewrite(filePath, cmdType, parm)
 
 	case dbflex.QueryInsert:
 		data, hasData := parm["data"]
 		if !hasData {
 			return nil, toolkit.Errorf("insert fail, no data")
 		}
-		txt, err := objToText(data, cfg)
+		if cfg.FixedWidth {
+			cfg = cfg.forType(reflect.Indirect(reflect.ValueOf(data)).Type())
+		}
+
+		var file *os.File
+		fileExist := false
+		if _, err = os.Stat(filePath); err == nil {
+			fileExist = true
+		}
+
+		//-- create the file if it is not exist yet
+		if !fileExist {
+			file, err = os.Create(filePath)
+			if err != nil {
+				return nil, toolkit.Errorf("unable to create file %s. %s", filePath, err.Error())
+			}
+		} else {
+			file, err = os.OpenFile(filePath, os.O_APPEND|os.O_RDWR, os.ModeAppend)
+			if err != nil {
+				return nil, toolkit.Errorf("unable to open file %s. %s", filePath, err.Error())
+			}
+		}
+
+		defer func() {
+			file.Close()
+		}()
+
+		w, err := newTextWriter(file, compressionOf(filePath))
+		if err != nil {
+			return nil, toolkit.Errorf("unable to write to text file %s. %s", filePath, err.Error())
+		}
+
+		headers := q.Config("fields", []string{}).([]string)
+		if cfg.UseHeader {
+			fileHeaders, err := readFileHeaders(filePath, cfg)
+			if err != nil {
+				return nil, toolkit.Errorf("unable to read header of %s. %s", filePath, err.Error())
+			}
+
+			if len(fileHeaders) > 0 {
+				headers = fileHeaders
+			} else {
+				//-- new file, write the header first
+				if len(headers) == 0 {
+					if headers, _, _, err = objTextValues(data, cfg); err != nil {
+						return nil, toolkit.Errorf("error serializing data into text. %s", err.Error())
+					}
+				}
+				//-- skipped lines are kept empty so file can be read back using the same setting
+				if _, err = io.WriteString(w, strings.Repeat("\n", cfg.SkipLines)+
+					headerToText(headers, cfg)+"\n"); err != nil {
+					return nil, toolkit.Errorf("unable to write to text file %s. %s", filePath, err.Error())
+				}
+			}
+		}
+
+		if len(headers) == 0 {
+			//-- columns of file without header follow its schema file if any
+			schema, err := q.Connection().(*Connection).readSchema(q.Config(dbflex.ConfigKeyTableName, "").(string))
+			if err != nil {
+				return nil, err
+			}
+			if schema != nil {
+				for _, field := range schema.Fields {
+					headers = append(headers, field.Name)
+				}
+			}
+		}
+
+		txt, err := objToText(data, cfg, headers...)
 		if err != nil {
 			return nil, toolkit.Errorf("error serializing data into text. %s", err.Error())
 		}
 
-		txt = "something to write"
-		_, err = file.WriteString(txt + "\n")
+		_, err = io.WriteString(w, txt+"\n")
+		if err != nil {
+			return nil, toolkit.Errorf("unable to write to text file %s. %s", filePath, err.Error())
+		}
+		err = w.Close()
 		if err != nil {
 			return nil, toolkit.Errorf("unable to write to text file %s. %s", filePath, err.Error())
 		}
@@ -118,37 +210,244 @@ func (q *Query) Execute(parm toolkit.M) (interface{}, error) {
 		if err != nil {
 			return nil, toolkit.Errorf("unable to write to text file %s. %s", filePath, err.Error())
 		}
-	case dbflex.QueryDelete:
-		if !fileExist {
-			return nil, nil
+
+	default:
+		return nil, toolkit.Errorf("unknown command: %s", cmdType)
+	}
+
+	return nil, nil
+}
+
+// rewrite applies update or delete by streaming the file into a temp file on the same directory,
+// the temp file then replaces the original file. It returns number of affected lines
+func (q *Query) rewrite(filePath, cmdType string, parm toolkit.M) (int, error) {
+	cfg := q.textObjectSetting
+
+	stat, err := os.Stat(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
 		}
+		return 0, toolkit.Errorf("unable to open file %s. %s", filePath, err.Error())
+	}
 
-		deleteAll := false
-		if deleteAll {
-			stat, _ := file.Stat()
-			err = file.Truncate(stat.Size())
-			if err != nil {
-				return nil, toolkit.Errorf("unable to truncated %s", err.Error())
+	src, err := openTextFile(filePath)
+	if err != nil {
+		return 0, toolkit.Errorf("unable to open file %s. %s", filePath, err.Error())
+	}
+	defer src.Close()
+
+	tempFile, err := ioutil.TempFile(filepath.Dir(filePath), filepath.Base(filePath)+"_temp_")
+	if err != nil {
+		return 0, toolkit.Errorf("unable to create temp file. %s", err.Error())
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			tempFile.Close()
+			os.Remove(tempFile.Name())
+		}
+	}()
+
+	tw, err := newTextWriter(tempFile, compressionOf(filePath))
+	if err != nil {
+		return 0, toolkit.Errorf("unable to create temp file. %s", err.Error())
+	}
+	w := bufio.NewWriter(tw)
+	scanner := newLineScanner(src, cfg)
+
+	//-- skipped lines and header are kept as is
+	var headers []string
+	for i := 0; i < cfg.SkipLines && scanner.Scan(); i++ {
+		w.WriteString(scanner.Text() + "\n")
+	}
+	if cfg.UseHeader && scanner.Scan() {
+		headers = splitText(scanner.Text(), cfg)
+		for idx, header := range headers {
+			headers[idx] = strings.TrimSpace(header)
+		}
+		w.WriteString(scanner.Text() + "\n")
+	}
+
+	var data interface{}
+	if parm != nil {
+		data = parm.Get("data")
+	}
+
+	conn := q.Connection().(*Connection)
+	names, cfg, err := conn.tableColumns(q.Config(dbflex.ConfigKeyTableName, "").(string), filePath, headers, cfg)
+	if err != nil {
+		return 0, err
+	}
+	if len(names) == 0 && data != nil {
+		if rv := reflect.Indirect(reflect.ValueOf(data)); rv.Kind() == reflect.Struct {
+			names = structFieldNames(rv.Type())
+		}
+	}
+	if cfg.FixedWidth {
+		if data != nil {
+			cfg = cfg.forType(reflect.Indirect(reflect.ValueOf(data)).Type())
+		}
+		names = cfg.fixedNames()
+	}
+
+	var (
+		updates     map[string]interface{}
+		dateKeys    map[string]string
+		updateItems []*dbflex.UpdateItem
+	)
+	if cmdType == dbflex.QueryUpdate {
+		if updates, dateKeys, err = q.updateValues(data); err != nil {
+			return 0, err
+		}
+		parts := q.Config(dbflex.ConfigKeyGroupedQueryItems, dbflex.GroupedQueryItems{}).(dbflex.GroupedQueryItems)
+		for _, item := range parts[dbflex.QueryModify] {
+			updateItems = append(updateItems, item.Value.([]*dbflex.UpdateItem)...)
+		}
+		if len(updates) == 0 && len(updateItems) == 0 {
+			return 0, toolkit.Errorf("update need to have data or update items")
+		}
+	}
+
+	filter, _ := q.Config(dbflex.ConfigKeyFilter, nil).(*dbflex.Filter)
+	affected := 0
+	for scanner.Scan() {
+		line := scanner.Text()
+		m := toolkit.M{}
+		if err = textToObj(line, &m, cfg, names...); err != nil {
+			return 0, toolkit.Errorf("unable to parse %s. %s", line, err.Error())
+		}
+		match, err := filter.Match(m)
+		if err != nil {
+			return 0, err
+		}
+
+		if match {
+			affected++
+			if cmdType == dbflex.QueryDelete {
+				continue
 			}
-		} else {
-			var tempFile *os.File
-			tempFileName := filePath + "_temp_" + toolkit.RandomString(32)
-			if tempFile, err = os.Create(tempFileName); err != nil {
-				return nil, toolkit.Errorf("unable to create temp file. %s", err.Error())
+			if line, err = updateLine(line, m, names, updates, dateKeys, updateItems, cfg); err != nil {
+				return 0, err
 			}
-			defer tempFile.Close()
+		}
+
+		if _, err = w.WriteString(line + "\n"); err != nil {
+			return 0, toolkit.Errorf("unable to write temp file. %s", err.Error())
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return 0, toolkit.Errorf("unable to read file %s. %s", filePath, err.Error())
+	}
 
-			reader := bufio.NewScanner(file)
-			for reader.Scan() {
-				txt := reader.Text()
-				tempFile.WriteString(txt + "\n")
+	if err = w.Flush(); err != nil {
+		return 0, toolkit.Errorf("unable to write temp file. %s", err.Error())
+	}
+	if err = tw.Close(); err != nil {
+		return 0, toolkit.Errorf("unable to write temp file. %s", err.Error())
+	}
+	if err = tempFile.Sync(); err != nil {
+		return 0, toolkit.Errorf("unable to write temp file. %s", err.Error())
+	}
+	if err = tempFile.Close(); err != nil {
+		return 0, toolkit.Errorf("unable to write temp file. %s", err.Error())
+	}
+	os.Chmod(tempFile.Name(), stat.Mode())
+	src.Close()
+
+	if err = os.Rename(tempFile.Name(), filePath); err != nil {
+		return 0, toolkit.Errorf("unable to replace file %s. %s", filePath, err.Error())
+	}
+	committed = true
+	return affected, nil
+}
+
+// updateValues returns values to be updated keyed by lower case field name, limited to update fields if any
+func (q *Query) updateValues(data interface{}) (map[string]interface{}, map[string]string, error) {
+	if data == nil {
+		return map[string]interface{}{}, map[string]string{}, nil
+	}
+
+	_, values, dateKeys, err := objTextValues(data, q.textObjectSetting)
+	if err != nil {
+		return nil, nil, toolkit.Errorf("error serializing data into text. %s", err.Error())
+	}
+
+	fields := q.Config("fields", []string{}).([]string)
+	if len(fields) == 0 {
+		return values, dateKeys, nil
+	}
+
+	selected := map[string]interface{}{}
+	for _, field := range fields {
+		if v, ok := values[strings.ToLower(field)]; ok {
+			selected[strings.ToLower(field)] = v
+		}
+	}
+	return selected, dateKeys, nil
+}
+
+// updateLine returns the line after update being applied, untouched columns are kept as is
+func updateLine(line string, m toolkit.M, names []string, updates map[string]interface{}, dateKeys map[string]string,
+	updateItems []*dbflex.UpdateItem, cfg *TextObjSetting) (string, error) {
+	_, raws := splitColumns(line, cfg)
+	for len(raws) < len(names) {
+		raws = append(raws, "")
+	}
+
+	columnIndex := func(field string) int {
+		for idx, name := range names {
+			if strings.ToLower(name) == strings.ToLower(field) {
+				return idx
 			}
-			tempFile.Sync()
 		}
+		return -1
+	}
 
-	default:
-		return nil, toolkit.Errorf("unknown command: %s", cmdType)
+	columnText := func(idx int, v interface{}, dateKey string) (string, error) {
+		txt := cfg.valueToText(v, dateKey)
+		if cfg.FixedWidth {
+			return cfg.fixedText(cfg.Fields[idx], txt, v)
+		}
+		return txt, nil
 	}
 
-	return nil, nil
+	var err error
+	for idx, name := range names {
+		if v, ok := updates[strings.ToLower(name)]; ok {
+			dateKey := dateKeys[strings.ToLower(name)]
+			if dateKey == "" {
+				dateKey = name
+			}
+			if raws[idx], err = columnText(idx, v, dateKey); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	for _, item := range updateItems {
+		idx := columnIndex(item.Field)
+		if idx < 0 {
+			return "", toolkit.Errorf("field %s could not be found", item.Field)
+		}
+		v, err := item.Apply(m.Get(names[idx]))
+		if err != nil {
+			return "", err
+		}
+		m.Set(names[idx], v)
+		if raws[idx], err = columnText(idx, v, names[idx]); err != nil {
+			return "", err
+		}
+	}
+
+	return cfg.joinColumns(line, raws), nil
+}
+
+func readFileHeaders(filePath string, cfg *TextObjSetting) ([]string, error) {
+	f, err := openTextFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readHeaders(newLineScanner(f, cfg), cfg)
 }
@@ -0,0 +1,193 @@
+package text
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/eaciit/toolkit"
+)
+
+// DefaultMaxRecordSize is maximum size in bytes of a record if TextObjSetting.MaxRecordSize is not defined
+var DefaultMaxRecordSize = 1024 * 1024
+
+// recordSplitter splits text into records. A newline inside quoted field does not end a record.
+// A blank line after skipped lines and header is not a record, so it is neither read, counted nor rewritten
+type recordSplitter struct {
+	cfg       *TextObjSetting
+	line      int
+	startLine int
+	preamble  int
+	tokens    int
+}
+
+// newLineScanner returns a scanner that reads a record on each scan. Quote signs of cfg are respected,
+// so a quoted field could span multiple lines
+func newLineScanner(r io.Reader, cfg *TextObjSetting) *bufio.Scanner {
+	maxSize := DefaultMaxRecordSize
+	if cfg != nil && cfg.MaxRecordSize > 0 {
+		maxSize = cfg.MaxRecordSize
+	}
+	initSize := 64 * 1024
+	if initSize > maxSize {
+		initSize = maxSize
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, initSize), maxSize)
+	splitter := &recordSplitter{cfg: cfg}
+	if cfg != nil {
+		splitter.preamble = cfg.SkipLines
+		if cfg.UseHeader {
+			splitter.preamble++
+		}
+	}
+	scanner.Split(splitter.split)
+	return scanner
+}
+
+func (s *recordSplitter) useSign() bool {
+	return s.cfg != nil && s.cfg.UseSign && !s.cfg.FixedWidth
+}
+
+func (s *recordSplitter) split(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+
+	inQuote := false
+	fieldBlank := true
+	newlines := 0
+	var closeQuote rune
+	for i := 0; i < len(data); {
+		r, size := utf8.DecodeRune(data[i:])
+		if s.useSign() {
+			if !inQuote {
+				if _, closeSign, ok := s.cfg.sign(r); ok && fieldBlank {
+					inQuote = true
+					closeQuote = closeSign
+				} else if r == s.cfg.Delimeter {
+					fieldBlank = true
+				} else if !unicode.IsSpace(r) {
+					fieldBlank = false
+				}
+			} else if r == closeQuote {
+				if i+size >= len(data) && !atEOF {
+					//-- need next rune to decide whether it is an escaped quote
+					return 0, nil, nil
+				}
+				if next, nextSize := utf8.DecodeRune(data[i+size:]); i+size < len(data) && next == closeQuote {
+					i += size + nextSize
+					continue
+				}
+				inQuote = false
+				fieldBlank = false
+			}
+		}
+
+		if r == '\n' {
+			if !inQuote {
+				if s.isBlank(data[:i]) {
+					//-- blank line is not a record
+					s.line++
+					return i + 1, nil, nil
+				}
+				token, err := s.token(data[:i], newlines+1)
+				return i + 1, token, err
+			}
+			newlines++
+		}
+		i += size
+	}
+
+	if !atEOF {
+		return 0, nil, nil
+	}
+	if inQuote && s.cfg.Strict {
+		return 0, nil, toolkit.Errorf("malformed record at line %d: quoted field is not closed", s.line+1)
+	}
+	if s.isBlank(data) {
+		s.line += newlines
+		return len(data), nil, nil
+	}
+	token, err := s.token(data, newlines)
+	return len(data), token, err
+}
+
+// isBlank reports whether data is a blank line to be skipped, lines of the preamble are kept as is
+func (s *recordSplitter) isBlank(data []byte) bool {
+	return s.tokens >= s.preamble && len(bytes.TrimSpace(data)) == 0
+}
+
+func (s *recordSplitter) token(data []byte, lines int) ([]byte, error) {
+	s.tokens++
+	s.startLine = s.line + 1
+	s.line += lines
+
+	token := bytes.TrimSuffix(data, []byte{'\r'})
+	if s.cfg != nil && s.cfg.Strict && s.useSign() {
+		if err := validateRecord(string(token), s.cfg); err != nil {
+			return nil, toolkit.Errorf("malformed record at line %d: %s", s.startLine, err.Error())
+		}
+	}
+	return token, nil
+}
+
+// sign returns open and close rune of the quote sign started by r
+func (t *TextObjSetting) sign(r rune) (rune, rune, bool) {
+	for _, sign := range t.Signs {
+		if len(sign) > 0 && sign[0] == r {
+			if len(sign) == 1 {
+				return r, r, true
+			}
+			return r, sign[1], true
+		}
+	}
+	return 0, 0, false
+}
+
+// validateRecord checks that quote sign only opens a field when it is the first rune of the field after
+// leading spaces, a quoted field is followed by delimiter, and quote sign inside quoted field is escaped by
+// doubling it. Quote sign inside an unquoted field, such as O'Brien, is a plain character
+func validateRecord(txt string, cfg *TextObjSetting) error {
+	runes := []rune(txt)
+	pos := 0
+	for field := 1; ; field++ {
+		for pos < len(runes) && runes[pos] != cfg.Delimeter && unicode.IsSpace(runes[pos]) {
+			pos++
+		}
+		if pos < len(runes) {
+			if _, closeSign, ok := cfg.sign(runes[pos]); ok {
+				//-- quoted field, find its close quote
+				closed := false
+				for pos++; pos < len(runes); pos++ {
+					if runes[pos] == closeSign {
+						if pos+1 < len(runes) && runes[pos+1] == closeSign {
+							pos++
+							continue
+						}
+						closed = true
+						pos++
+						break
+					}
+				}
+				if !closed {
+					return toolkit.Errorf("quoted field %d is not closed", field)
+				}
+				if pos < len(runes) && runes[pos] != cfg.Delimeter {
+					return toolkit.Errorf("unexpected character after quoted field %d", field)
+				}
+			}
+		}
+
+		for pos < len(runes) && runes[pos] != cfg.Delimeter {
+			pos++
+		}
+		if pos >= len(runes) {
+			return nil
+		}
+		pos++
+	}
+}
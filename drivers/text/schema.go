@@ -0,0 +1,247 @@
+package text
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eaciit/dbflex"
+	"github.com/eaciit/toolkit"
+)
+
+// DescribeSampleSize is number of records being sampled to infer column types
+var DescribeSampleSize = 100
+
+// SchemaFileExtension is appended to table name to name its sidecar schema file. Schema file is a json
+// of dbflex.TableSchema, each field declares column name and its GoType
+const SchemaFileExtension = ".schema.json"
+
+func (c *Connection) schemaFilePath(tablename string) string {
+	return filepath.Join(c.dirPath, tablename+SchemaFileExtension)
+}
+
+func isSchemaFile(name string) bool {
+	return strings.HasSuffix(strings.ToLower(name), SchemaFileExtension)
+}
+
+// readSchema reads sidecar schema file of a table, it returns nil if table has no schema file
+func (c *Connection) readSchema(tablename string) (*dbflex.TableSchema, error) {
+	bs, err := ioutil.ReadFile(c.schemaFilePath(tablename))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	schema := new(dbflex.TableSchema)
+	if err = json.Unmarshal(bs, schema); err != nil {
+		return nil, toolkit.Errorf("invalid schema file of %s. %s", tablename, err.Error())
+	}
+	if schema.Name == "" {
+		schema.Name = tablename
+	}
+	return schema, nil
+}
+
+// WriteSchema writes sidecar schema file of a table, schema returned by Describe could be used as a start
+func (c *Connection) WriteSchema(schema *dbflex.TableSchema) error {
+	bs, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.schemaFilePath(schema.Name), bs, 0644)
+}
+
+// Describe returns sidecar schema of a table if it exists, otherwise schema is inferred by sampling its records.
+// Column is named by its header, or by its position if header is not used
+func (c *Connection) Describe(tablename string) (*dbflex.TableSchema, error) {
+	schema, err := c.readSchema(tablename)
+	if err != nil || schema != nil {
+		return schema, err
+	}
+
+	filePath := c.tableFilePath(tablename)
+	lock, err := c.lock(filePath, false)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	names, nullables, err := c.inferColumnTypes(filePath, c.textObjSetting)
+	if err != nil {
+		return nil, err
+	}
+
+	schema = &dbflex.TableSchema{Name: tablename}
+	for _, name := range names {
+		schema.Fields = append(schema.Fields, &dbflex.FieldSchema{Name: name.name, NativeType: "text",
+			GoType: name.goType, Nullable: nullables[name.name]})
+	}
+	return schema, nil
+}
+
+type columnType struct {
+	name   string
+	goType string
+}
+
+// inferColumnTypes samples records of a file and returns a type of each column that fits all sampled values.
+// Number with leading zero such as 007 is kept as string
+func (c *Connection) inferColumnTypes(filePath string, cfg *TextObjSetting) ([]columnType, map[string]bool, error) {
+	f, err := openTextFile(filePath)
+	if err != nil {
+		return nil, nil, toolkit.Errorf("unable to open %s. %s", filePath, err.Error())
+	}
+	defer f.Close()
+
+	scanner := newLineScanner(f, cfg)
+	headers, err := readHeaders(scanner, cfg)
+	if err != nil {
+		return nil, nil, toolkit.Errorf("unable to read header of %s. %s", filePath, err.Error())
+	}
+
+	columns := []columnType{}
+	for _, header := range headers {
+		columns = append(columns, columnType{name: header})
+	}
+	nullables := map[string]bool{}
+
+	for read := 0; read < DescribeSampleSize && scanner.Scan(); read++ {
+		for idx, txt := range splitText(scanner.Text(), cfg) {
+			if idx >= len(columns) {
+				columns = append(columns, columnType{name: toolkit.ToString(idx)})
+			}
+			name := columns[idx].name
+			if strings.TrimSpace(txt) == "" {
+				nullables[name] = true
+				continue
+			}
+			columns[idx].goType = mergeType(columns[idx].goType, textType(txt, cfg.DateFormat(name)))
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, nil, toolkit.Errorf("unable to read %s. %s", filePath, err.Error())
+	}
+
+	for idx := range columns {
+		if columns[idx].goType == "" {
+			columns[idx].goType = "string"
+		}
+	}
+	return columns, nullables, nil
+}
+
+// textType returns the most specific type of a text value
+func textType(txt, dateFormat string) string {
+	leadingZero := func(s string) bool {
+		s = strings.TrimLeft(s, "+-")
+		return len(s) > 1 && s[0] == '0' && s[1] >= '0' && s[1] <= '9'
+	}
+
+	if _, err := strconv.ParseInt(txt, 10, 64); err == nil {
+		if leadingZero(txt) {
+			return "string"
+		}
+		return "int"
+	}
+	if _, err := strconv.ParseFloat(txt, 64); err == nil {
+		if leadingZero(txt) {
+			return "string"
+		}
+		return "float64"
+	}
+	if formatDate(parseDate(txt, dateFormat), dateFormat) == txt {
+		return "time.Time"
+	}
+	if lower := strings.ToLower(txt); lower == "true" || lower == "false" {
+		return "bool"
+	}
+	return "string"
+}
+
+// mergeType returns a type that fits values of both types
+func mergeType(a, b string) string {
+	switch {
+	case a == "" || a == b:
+		return b
+	case (a == "int" && b == "float64") || (a == "float64" && b == "int"):
+		return "float64"
+	}
+	return "string"
+}
+
+// tableColumns returns column names of a table and setting to read its records into map with consistent
+// type per column. Names and types are taken from schema file if exists, otherwise they are inferred
+func (c *Connection) tableColumns(tablename, filePath string, headers []string,
+	cfg *TextObjSetting) ([]string, *TextObjSetting, error) {
+	types := map[string]string{}
+	schema, err := c.readSchema(tablename)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if schema != nil {
+		names := []string{}
+		for _, field := range schema.Fields {
+			names = append(names, field.Name)
+			if field.GoType != "" {
+				types[strings.ToLower(field.Name)] = field.GoType
+			}
+		}
+		if len(headers) == 0 {
+			headers = names
+		}
+		return headers, cfg.withColumnTypes(types), nil
+	}
+
+	if types, err = c.inferredTypes(filePath, cfg); err != nil {
+		return nil, nil, err
+	}
+	return headers, cfg.withColumnTypes(types), nil
+}
+
+type columnCacheItem struct {
+	size    int64
+	modTime time.Time
+	cfg     *TextObjSetting
+	types   map[string]string
+}
+
+// inferredTypes returns inferred type per lower case column name. Result is cached per file and reused
+// as long as size and modification time of the file and the setting are not changed, so every operation
+// on an unchanged file reads its records with the same types. Returned map should not be changed
+func (c *Connection) inferredTypes(filePath string, cfg *TextObjSetting) (map[string]string, error) {
+	fi, statErr := os.Stat(filePath)
+	if statErr == nil {
+		c.columnsMutex.Lock()
+		item, ok := c.columns[filePath]
+		c.columnsMutex.Unlock()
+		if ok && item.cfg == cfg && item.size == fi.Size() && item.modTime.Equal(fi.ModTime()) {
+			return item.types, nil
+		}
+	}
+
+	columns, _, err := c.inferColumnTypes(filePath, cfg)
+	if err != nil {
+		return nil, err
+	}
+	types := map[string]string{}
+	for _, column := range columns {
+		types[strings.ToLower(column.name)] = column.goType
+	}
+
+	if statErr == nil {
+		c.columnsMutex.Lock()
+		if c.columns == nil {
+			c.columns = map[string]*columnCacheItem{}
+		}
+		c.columns[filePath] = &columnCacheItem{fi.Size(), fi.ModTime(), cfg, types}
+		c.columnsMutex.Unlock()
+	}
+	return types, nil
+}
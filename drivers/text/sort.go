@@ -0,0 +1,209 @@
+package text
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/eaciit/toolkit"
+
+	"github.com/eaciit/dbflex/drivers/internal/docutil"
+)
+
+// SortChunkSize is maximum number of lines being sorted in memory. Larger result will be sorted
+// per chunk into temp files and merged afterward
+var SortChunkSize = 10000
+
+type lineSource interface {
+	Next() (string, bool, error)
+	Close()
+}
+
+type sortRecord struct {
+	line string
+	m    toolkit.M
+}
+
+type sliceSource struct {
+	records []*sortRecord
+	idx     int
+}
+
+func (s *sliceSource) Next() (string, bool, error) {
+	if s.idx >= len(s.records) {
+		return "", false, nil
+	}
+	s.idx++
+	return s.records[s.idx-1].line, true, nil
+}
+
+func (s *sliceSource) Close() {
+	s.records = nil
+}
+
+type fileSource struct {
+	f       *os.File
+	scanner *bufio.Scanner
+}
+
+func (s *fileSource) Next() (string, bool, error) {
+	if !s.scanner.Scan() {
+		return "", false, s.scanner.Err()
+	}
+	return s.scanner.Text(), true, nil
+}
+
+func (s *fileSource) Close() {
+	s.f.Close()
+	os.Remove(s.f.Name())
+}
+
+// sortLines reads all records from next and returns them sorted. parse is used to read back
+// a line that has been written into chunk file
+func sortLines(next func() (*sortRecord, error), parse func(string) (toolkit.M, error), sorts []string, cfg *TextObjSetting) (lineSource, error) {
+	chunk := []*sortRecord{}
+	chunkFiles := []string{}
+	cleanup := func() {
+		for _, name := range chunkFiles {
+			os.Remove(name)
+		}
+	}
+
+	for {
+		record, err := next()
+		if err != nil {
+			cleanup()
+			return nil, err
+		}
+		if record == nil {
+			break
+		}
+
+		chunk = append(chunk, record)
+		if len(chunk) >= SortChunkSize {
+			name, err := writeSortedChunk(chunk, sorts)
+			if err != nil {
+				cleanup()
+				return nil, err
+			}
+			chunkFiles = append(chunkFiles, name)
+			chunk = []*sortRecord{}
+		}
+	}
+
+	if len(chunkFiles) == 0 {
+		sort.SliceStable(chunk, func(i, j int) bool {
+			return docutil.CompareDocument(chunk[i].m, chunk[j].m, sorts) < 0
+		})
+		return &sliceSource{records: chunk}, nil
+	}
+
+	if len(chunk) > 0 {
+		name, err := writeSortedChunk(chunk, sorts)
+		if err != nil {
+			cleanup()
+			return nil, err
+		}
+		chunkFiles = append(chunkFiles, name)
+	}
+
+	defer cleanup()
+	return mergeChunks(chunkFiles, parse, sorts, cfg)
+}
+
+func writeSortedChunk(chunk []*sortRecord, sorts []string) (string, error) {
+	sort.SliceStable(chunk, func(i, j int) bool {
+		return docutil.CompareDocument(chunk[i].m, chunk[j].m, sorts) < 0
+	})
+
+	f, err := ioutil.TempFile("", "dbflex_text_sort_")
+	if err != nil {
+		return "", toolkit.Errorf("unable to create sort file. %s", err.Error())
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	for _, record := range chunk {
+		if _, err = w.WriteString(record.line + "\n"); err != nil {
+			os.Remove(f.Name())
+			return "", toolkit.Errorf("unable to write sort file. %s", err.Error())
+		}
+	}
+	if err = w.Flush(); err != nil {
+		os.Remove(f.Name())
+		return "", toolkit.Errorf("unable to write sort file. %s", err.Error())
+	}
+	return f.Name(), nil
+}
+
+func mergeChunks(chunkFiles []string, parse func(string) (toolkit.M, error), sorts []string, cfg *TextObjSetting) (lineSource, error) {
+	out, err := ioutil.TempFile("", "dbflex_text_sort_")
+	if err != nil {
+		return nil, toolkit.Errorf("unable to create sort file. %s", err.Error())
+	}
+	fail := func(err error) (lineSource, error) {
+		out.Close()
+		os.Remove(out.Name())
+		return nil, err
+	}
+
+	scanners := make([]*bufio.Scanner, len(chunkFiles))
+	heads := make([]*sortRecord, len(chunkFiles))
+	for idx, name := range chunkFiles {
+		f, err := os.Open(name)
+		if err != nil {
+			return fail(toolkit.Errorf("unable to open sort file. %s", err.Error()))
+		}
+		defer f.Close()
+		scanners[idx] = newLineScanner(f, cfg)
+	}
+
+	advance := func(idx int) error {
+		heads[idx] = nil
+		if !scanners[idx].Scan() {
+			return scanners[idx].Err()
+		}
+		line := scanners[idx].Text()
+		m, err := parse(line)
+		if err != nil {
+			return err
+		}
+		heads[idx] = &sortRecord{line, m}
+		return nil
+	}
+
+	for idx := range scanners {
+		if err = advance(idx); err != nil {
+			return fail(err)
+		}
+	}
+
+	w := bufio.NewWriter(out)
+	for {
+		minIdx := -1
+		for idx, head := range heads {
+			if head != nil && (minIdx == -1 || docutil.CompareDocument(head.m, heads[minIdx].m, sorts) < 0) {
+				minIdx = idx
+			}
+		}
+		if minIdx == -1 {
+			break
+		}
+
+		if _, err = w.WriteString(heads[minIdx].line + "\n"); err != nil {
+			return fail(toolkit.Errorf("unable to write sort file. %s", err.Error()))
+		}
+		if err = advance(minIdx); err != nil {
+			return fail(err)
+		}
+	}
+	if err = w.Flush(); err != nil {
+		return fail(toolkit.Errorf("unable to write sort file. %s", err.Error()))
+	}
+
+	if _, err = out.Seek(0, 0); err != nil {
+		return fail(err)
+	}
+	return &fileSource{f: out, scanner: newLineScanner(out, cfg)}, nil
+}
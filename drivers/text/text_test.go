@@ -1,12 +1,18 @@
 package text
 
 import (
+	"io/ioutil"
 	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/eaciit/toolkit"
 
+	"github.com/eaciit/dbflex"
 	"github.com/eaciit/dbflex/testbase"
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -129,9 +135,634 @@ func TestTextToObj(t *testing.T) {
 	})
 }
 func TestCRUD(t *testing.T) {
-	workpath := "/Users/ariefdarmawan/Go/src/github.com/eaciit/dbflex/data"
+	workpath, _ := ioutil.TempDir("", "dbflextext")
+	defer os.RemoveAll(workpath)
+
 	crud := testbase.NewCRUD(t, toolkit.Sprintf("text://localhost/%s?extension=csv&separator=comma", workpath),
-		100,
+		1000,
 		toolkit.M{}.Set("conn_config", toolkit.M{}.Set("text_object_setting", cfg)))
 	crud.RunTest("clear", "insert", "read")
 }
+
+func TestDescribe(t *testing.T) {
+	Convey("Describe text table", t, func() {
+		workpath, _ := ioutil.TempDir("", "dbflextext")
+		defer os.RemoveAll(workpath)
+		ioutil.WriteFile(filepath.Join(workpath, "items.csv"),
+			[]byte("\"Item1\",10,\"2018-06-15 10:00:00\"\n\"Item2\",20.5,\"2018-06-16 10:00:00\"\n"), 0644)
+
+		conn, _ := dbflex.NewConnectionFromUri(toolkit.Sprintf("text://localhost/%s?extension=csv", workpath),
+			toolkit.M{}.Set("text_obj_setting", cfg))
+		So(conn.Connect(), ShouldBeNil)
+
+		schema, err := conn.Describe("items")
+		So(err, ShouldBeNil)
+		So(len(schema.Fields), ShouldEqual, 3)
+		So(schema.Fields[0].GoType, ShouldEqual, "string")
+		So(schema.Fields[1].GoType, ShouldEqual, "float64")
+		So(schema.Fields[2].GoType, ShouldEqual, "time.Time")
+	})
+}
+
+func TestObjToText(t *testing.T) {
+	Convey("Serialize object to text", t, func() {
+		type fakeModel struct {
+			ID          string
+			Title       string
+			NumberInt   int64
+			NumberFloat float64
+			Active      bool
+			Created     time.Time
+		}
+
+		fm := &fakeModel{"Record1", "Title with \"quote\", and comma", 30, 20.5, true,
+			toolkit.ToDate("2018-06-15 10:00:00", "yyyy-MM-dd hh:mm:ss")}
+
+		Convey("From Obj", func() {
+			txt, err := objToText(fm, cfg)
+			So(err, ShouldBeNil)
+			So(txt, ShouldEqual, "\"Record1\",\"Title with \"\"quote\"\", and comma\",30,20.5,true,\"2018-06-15 10:00:00\"")
+
+			Convey("Read back", func() {
+				fm2 := new(fakeModel)
+				err := textToObj(txt, fm2, cfg)
+				So(err, ShouldBeNil)
+				So(*fm2, ShouldResemble, *fm)
+			})
+		})
+
+		Convey("From M with headers", func() {
+			m := toolkit.M{}.Set("ID", "Record1").Set("NumberInt", 30).Set("Title", "Title 1")
+			txt, err := objToText(m, cfg, "Title", "ID", "NumberInt")
+			So(err, ShouldBeNil)
+			So(txt, ShouldEqual, "\"Title 1\",\"Record1\",30")
+		})
+	})
+}
+
+func TestHeader(t *testing.T) {
+	Convey("Text with header", t, func() {
+		type headerModel struct {
+			ID     string `sql:"emp_id"`
+			Name   string
+			Salary int
+		}
+
+		workpath, _ := ioutil.TempDir("", "dbflextext")
+		defer os.RemoveAll(workpath)
+		hcfg := NewTextObjSetting(',').SetUseHeader(true).SetSkipLines(1)
+
+		conn, _ := dbflex.NewConnectionFromUri(toolkit.Sprintf("text://localhost/%s?extension=csv", workpath),
+			toolkit.M{}.Set("text_obj_setting", hcfg))
+		So(conn.Connect(), ShouldBeNil)
+
+		Convey("Insert writes header on new file", func() {
+			for i := 1; i <= 3; i++ {
+				_, err := conn.Execute(dbflex.From("emps").Insert(),
+					toolkit.M{}.Set("data", &headerModel{toolkit.Sprintf("E%d", i), "Name", i * 100}))
+				So(err, ShouldBeNil)
+			}
+			bs, _ := ioutil.ReadFile(filepath.Join(workpath, "emps.csv"))
+			So(string(bs), ShouldStartWith, "\n\"emp_id\",\"Name\",\"Salary\"\n\"E1\",")
+
+			Convey("Fetch maps column by header", func() {
+				ioutil.WriteFile(filepath.Join(workpath, "emps.csv"),
+					[]byte("exported by system\nSALARY,Emp_ID\n100,\"E1\"\n200,\"E2\"\n"), 0644)
+
+				res := []headerModel{}
+				err := conn.Cursor(dbflex.From("emps").Select(), nil).Fetchs(&res, 0)
+				So(err, ShouldBeNil)
+				So(len(res), ShouldEqual, 2)
+				So(res[1].ID, ShouldEqual, "E2")
+				So(res[1].Salary, ShouldEqual, 200)
+
+				ms := []toolkit.M{}
+				err = conn.Cursor(dbflex.From("emps").Select(), nil).Fetchs(&ms, 0)
+				So(err, ShouldBeNil)
+				So(ms[0].GetString("Emp_ID"), ShouldEqual, "E1")
+			})
+		})
+	})
+}
+
+func TestQuery(t *testing.T) {
+	Convey("Query text table", t, func() {
+		type itemModel struct {
+			ID    string
+			Grade int
+			Price float64
+		}
+
+		workpath, _ := ioutil.TempDir("", "dbflextext")
+		defer os.RemoveAll(workpath)
+		conn, _ := dbflex.NewConnectionFromUri(toolkit.Sprintf("text://localhost/%s?extension=csv", workpath),
+			toolkit.M{}.Set("text_obj_setting", NewTextObjSetting(',')))
+		So(conn.Connect(), ShouldBeNil)
+
+		for i := 1; i <= 50; i++ {
+			_, err := conn.Execute(dbflex.From("items").Insert(),
+				toolkit.M{}.Set("data", &itemModel{toolkit.Sprintf("Item-%02d", i), i % 5, float64(i) * 1.5}))
+			So(err, ShouldBeNil)
+		}
+
+		Convey("Where", func() {
+			res := []itemModel{}
+			err := conn.Cursor(dbflex.From("items").Select().Where(dbflex.And(
+				dbflex.Eq("grade", 3), dbflex.Gt("price", 30))), nil).Fetchs(&res, 0)
+			So(err, ShouldBeNil)
+			So(len(res), ShouldEqual, 6)
+			for _, r := range res {
+				So(r.Grade, ShouldEqual, 3)
+				So(r.Price, ShouldBeGreaterThan, 30)
+			}
+		})
+
+		Convey("Contains, in and range", func() {
+			res := []itemModel{}
+			err := conn.Cursor(dbflex.From("items").Select().Where(dbflex.Or(
+				dbflex.Contains("id", "-1"), dbflex.In("grade", 0), dbflex.Range("price", 60, 63))), nil).Fetchs(&res, 0)
+			So(err, ShouldBeNil)
+			So(len(res), ShouldEqual, 10+10-2+2)
+		})
+
+		Convey("Order, skip and take", func() {
+			res := []itemModel{}
+			err := conn.Cursor(dbflex.From("items").Select().Where(dbflex.Eq("grade", 2)).
+				OrderBy("-price").Skip(1).Take(3), nil).Fetchs(&res, 0)
+			So(err, ShouldBeNil)
+			So(len(res), ShouldEqual, 3)
+			So(res[0].ID, ShouldEqual, "Item-42")
+			So(res[2].ID, ShouldEqual, "Item-32")
+		})
+
+		Convey("Order using external sort", func() {
+			SortChunkSize = 7
+			defer func() { SortChunkSize = 10000 }()
+
+			res := []itemModel{}
+			err := conn.Cursor(dbflex.From("items").Select().OrderBy("grade", "-id"), nil).Fetchs(&res, 0)
+			So(err, ShouldBeNil)
+			So(len(res), ShouldEqual, 50)
+			So(res[0].ID, ShouldEqual, "Item-50")
+			So(res[49].ID, ShouldEqual, "Item-04")
+		})
+
+		Convey("Select", func() {
+			res := []itemModel{}
+			err := conn.Cursor(dbflex.From("items").Select("ID").Take(1), nil).Fetchs(&res, 0)
+			So(err, ShouldBeNil)
+			So(res[0].ID, ShouldEqual, "Item-01")
+			So(res[0].Price, ShouldEqual, 0)
+		})
+	})
+}
+
+func TestUpdateDelete(t *testing.T) {
+	Convey("Update and delete text table", t, func() {
+		type itemModel struct {
+			ID    string
+			Grade int
+			Price float64
+		}
+
+		workpath, _ := ioutil.TempDir("", "dbflextext")
+		defer os.RemoveAll(workpath)
+		conn, _ := dbflex.NewConnectionFromUri(toolkit.Sprintf("text://localhost/%s?extension=csv", workpath),
+			toolkit.M{}.Set("text_obj_setting", NewTextObjSetting(',').SetUseHeader(true)))
+		So(conn.Connect(), ShouldBeNil)
+
+		for i := 1; i <= 10; i++ {
+			_, err := conn.Execute(dbflex.From("items").Insert(),
+				toolkit.M{}.Set("data", &itemModel{toolkit.Sprintf("Item-%02d", i), i % 2, float64(i)}))
+			So(err, ShouldBeNil)
+		}
+
+		Convey("Update with filter", func() {
+			n, err := conn.Execute(dbflex.From("items").Update("Price").Where(dbflex.Eq("grade", 1)),
+				toolkit.M{}.Set("data", toolkit.M{}.Set("Price", 99)))
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 5)
+
+			res := []itemModel{}
+			So(conn.Cursor(dbflex.From("items").Select().Where(dbflex.Eq("price", 99)), nil).Fetchs(&res, 0), ShouldBeNil)
+			So(len(res), ShouldEqual, 5)
+			So(res[0].ID, ShouldEqual, "Item-01")
+			So(res[0].Grade, ShouldEqual, 1)
+		})
+
+		Convey("Update using modify", func() {
+			n, err := conn.Execute(dbflex.From("items").Where(dbflex.Eq("id", "Item-04")).
+				Modify(dbflex.Inc("grade", 10)), nil)
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 1)
+
+			res := []itemModel{}
+			So(conn.Cursor(dbflex.From("items").Select().Where(dbflex.Eq("id", "Item-04")), nil).Fetchs(&res, 0), ShouldBeNil)
+			So(res[0].Grade, ShouldEqual, 10)
+			So(res[0].Price, ShouldEqual, 4)
+		})
+
+		Convey("Delete with filter", func() {
+			n, err := conn.Execute(dbflex.From("items").Delete().Where(dbflex.Lte("price", 3)), nil)
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 3)
+
+			res := []itemModel{}
+			So(conn.Cursor(dbflex.From("items").Select(), nil).Fetchs(&res, 0), ShouldBeNil)
+			So(len(res), ShouldEqual, 7)
+			So(res[0].ID, ShouldEqual, "Item-04")
+
+			files, _ := filepath.Glob(filepath.Join(workpath, "*_temp_*"))
+			So(len(files), ShouldEqual, 0)
+		})
+	})
+}
+
+func TestAggregate(t *testing.T) {
+	workpath, _ := ioutil.TempDir("", "dbflextext")
+	defer os.RemoveAll(workpath)
+
+	crud := testbase.NewCRUD(t, toolkit.Sprintf("text://localhost/%s?extension=csv", workpath),
+		100,
+		toolkit.M{}.Set("conn_config", toolkit.M{}.Set("text_obj_setting",
+			NewTextObjSetting(',').SetUseHeader(true).SetDateFormat("", "yyyy-MM-dd HH:mm:ss"))))
+	crud.RunTest("clear", "insert", "aggregate")
+
+	Convey("Aggregate per group", t, func() {
+		conn, _ := dbflex.NewConnectionFromUri(toolkit.Sprintf("text://localhost/%s?extension=csv", workpath),
+			toolkit.M{}.Set("text_obj_setting", NewTextObjSetting(',').SetUseHeader(true)))
+		So(conn.Connect(), ShouldBeNil)
+
+		res := []toolkit.M{}
+		err := conn.Cursor(dbflex.From("employees").GroupBy("Grade").
+			Aggr(dbflex.Sum("Salary"), dbflex.Count("ID"), dbflex.NewAggrItem("MaxSalary", dbflex.AggrMax, "Salary")).OrderBy("-Grade").Take(3), nil).
+			Fetchs(&res, 0)
+		So(err, ShouldBeNil)
+		So(len(res), ShouldBeGreaterThan, 0)
+		So(len(res), ShouldBeLessThanOrEqualTo, 3)
+
+		grade := res[0].GetFloat64("Grade")
+		emps := []toolkit.M{}
+		So(conn.Cursor(dbflex.From("employees").Select().Where(dbflex.Eq("Grade", grade)), nil).Fetchs(&emps, 0), ShouldBeNil)
+		total, max := float64(0), float64(0)
+		for _, emp := range emps {
+			total += emp.GetFloat64("Salary")
+			max = math.Max(max, emp.GetFloat64("Salary"))
+		}
+		So(res[0].Get("_id").(map[string]interface{})["Grade"], ShouldEqual, grade)
+		So(res[0].GetFloat64("Salary"), ShouldEqual, total)
+		So(res[0].GetInt("ID"), ShouldEqual, len(emps))
+		So(res[0].GetFloat64("MaxSalary"), ShouldEqual, max)
+		So(len(res) == 1 || res[0].GetFloat64("Grade") > res[1].GetFloat64("Grade"), ShouldBeTrue)
+	})
+}
+
+func TestLock(t *testing.T) {
+	Convey("Concurrent access of text table", t, func() {
+		workpath, _ := ioutil.TempDir("", "dbflextext")
+		defer os.RemoveAll(workpath)
+
+		connect := func() dbflex.IConnection {
+			conn, _ := dbflex.NewConnectionFromUri(toolkit.Sprintf("text://localhost/%s?extension=csv&lock_timeout=200ms", workpath),
+				toolkit.M{}.Set("text_obj_setting", NewTextObjSetting(',')))
+			So(conn.Connect(), ShouldBeNil)
+			return conn
+		}
+		conn1, conn2 := connect(), connect()
+
+		Convey("Concurrent insert keeps every line intact", func() {
+			wg := new(sync.WaitGroup)
+			for _, conn := range []dbflex.IConnection{conn1, conn1, conn2, conn2} {
+				wg.Add(1)
+				go func(conn dbflex.IConnection) {
+					defer wg.Done()
+					for i := 0; i < 50; i++ {
+						conn.Execute(dbflex.From("items").Insert(),
+							toolkit.M{}.Set("data", toolkit.M{}.Set("ID", toolkit.RandomString(100))))
+					}
+				}(conn)
+			}
+			wg.Wait()
+
+			bs, _ := ioutil.ReadFile(filepath.Join(workpath, "items.csv"))
+			lines := strings.Split(strings.TrimSpace(string(bs)), "\n")
+			So(len(lines), ShouldEqual, 200)
+			for _, line := range lines {
+				So(len(strings.Trim(line, "\"")), ShouldEqual, 100)
+			}
+			So(conn1.ObjectNames(dbflex.ObjTypeTable), ShouldResemble, []string{"items"})
+		})
+
+		Convey("Writer waits until timeout", func() {
+			lock, err := conn1.(*Connection).lock(filepath.Join(workpath, "items.csv"), false)
+			So(err, ShouldBeNil)
+
+			_, err = conn2.Execute(dbflex.From("items").Insert(), toolkit.M{}.Set("data", toolkit.M{}.Set("ID", "A")))
+			So(err, ShouldNotBeNil)
+
+			lock.Unlock()
+			_, err = conn2.Execute(dbflex.From("items").Insert(), toolkit.M{}.Set("data", toolkit.M{}.Set("ID", "A")))
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Drop table removes its lock file", func() {
+			_, err := conn1.Execute(dbflex.From("items").Insert(), toolkit.M{}.Set("data", toolkit.M{}.Set("ID", "A")))
+			So(err, ShouldBeNil)
+			So(conn1.DropTable("items"), ShouldBeNil)
+			files, _ := ioutil.ReadDir(workpath)
+			So(len(files), ShouldEqual, 0)
+		})
+	})
+}
+
+func TestFixedWidth(t *testing.T) {
+	Convey("Fixed width text", t, func() {
+		type fixedModel struct {
+			ID     string  `fixed:"0,6"`
+			Name   string  `fixed:"6,10"`
+			Amount float64 `fixed:"16,8"`
+			Code   string  `fixed:"24,4,right"`
+		}
+
+		workpath, _ := ioutil.TempDir("", "dbflextext")
+		defer os.RemoveAll(workpath)
+		conn, _ := dbflex.NewConnectionFromUri(toolkit.Sprintf("text://localhost/%s?extension=txt", workpath),
+			toolkit.M{}.Set("text_obj_setting", NewTextObjSetting(',').SetFixedWidth()))
+		So(conn.Connect(), ShouldBeNil)
+
+		Convey("Insert pads each column", func() {
+			for i := 1; i <= 3; i++ {
+				_, err := conn.Execute(dbflex.From("feed").Insert(),
+					toolkit.M{}.Set("data", &fixedModel{toolkit.Sprintf("A%d", i), "Name", float64(i) * 10.5, "7"}))
+				So(err, ShouldBeNil)
+			}
+			bs, _ := ioutil.ReadFile(filepath.Join(workpath, "feed.txt"))
+			So(strings.Split(string(bs), "\n")[0], ShouldEqual, "A1    Name          10.5   7")
+
+			_, err := conn.Execute(dbflex.From("feed").Insert(),
+				toolkit.M{}.Set("data", &fixedModel{"A-TOO-LONG", "Name", 1, ""}))
+			So(err, ShouldNotBeNil)
+
+			Convey("Read, filter and update", func() {
+				res := []fixedModel{}
+				err := conn.Cursor(dbflex.From("feed").Select().Where(dbflex.Gt("amount", 15)), nil).Fetchs(&res, 0)
+				So(err, ShouldBeNil)
+				So(len(res), ShouldEqual, 2)
+				So(res[0].ID, ShouldEqual, "A2")
+				So(res[0].Amount, ShouldEqual, 21)
+				So(res[0].Code, ShouldEqual, "7")
+
+				n, err := conn.Execute(dbflex.From("feed").Update("Name").Where(dbflex.Eq("id", "A3")),
+					toolkit.M{}.Set("data", &fixedModel{Name: "Updated"}))
+				So(err, ShouldBeNil)
+				So(n, ShouldEqual, 1)
+				bs, _ := ioutil.ReadFile(filepath.Join(workpath, "feed.txt"))
+				So(strings.Split(string(bs), "\n")[2], ShouldEqual, "A3    Updated       31.5   7")
+			})
+
+			Convey("Fields declared on setting", func() {
+				mcfg := NewTextObjSetting(',').SetFixedWidth(
+					NewFixedField("Key", 0, 6), NewFixedField("Amount", 16, 8).SetAlign(AlignRight))
+				mconn, _ := dbflex.NewConnectionFromUri(toolkit.Sprintf("text://localhost/%s?extension=txt", workpath),
+					toolkit.M{}.Set("text_obj_setting", mcfg))
+				So(mconn.Connect(), ShouldBeNil)
+
+				ms := []toolkit.M{}
+				err := mconn.Cursor(dbflex.From("feed").Select().OrderBy("-amount"), nil).Fetchs(&ms, 0)
+				So(err, ShouldBeNil)
+				So(len(ms), ShouldEqual, 3)
+				So(ms[0].GetString("Key"), ShouldEqual, "A3")
+				So(ms[0].GetFloat64("Amount"), ShouldEqual, 31.5)
+			})
+		})
+	})
+}
+
+func TestCompression(t *testing.T) {
+	Convey("Compressed text table", t, func() {
+		type itemModel struct {
+			ID    string
+			Grade int
+		}
+
+		for compression, ext := range map[string]string{CompressionGzip: ".gz", CompressionZstd: ".zst"} {
+			workpath, _ := ioutil.TempDir("", "dbflextext")
+			defer os.RemoveAll(workpath)
+
+			conn, _ := dbflex.NewConnectionFromUri(toolkit.Sprintf("text://localhost/%s?extension=csv&compression=%s", workpath, compression),
+				toolkit.M{}.Set("text_obj_setting", NewTextObjSetting(',').SetUseHeader(true)))
+			So(conn.Connect(), ShouldBeNil)
+
+			for i := 1; i <= 5; i++ {
+				_, err := conn.Execute(dbflex.From("items").Insert(),
+					toolkit.M{}.Set("data", &itemModel{toolkit.Sprintf("Item-%d", i), i}))
+				So(err, ShouldBeNil)
+			}
+			_, err := conn.Execute(dbflex.From("items").Delete().Where(dbflex.Eq("grade", 2)), nil)
+			So(err, ShouldBeNil)
+			_, err = conn.Execute(dbflex.From("items").Where(dbflex.Eq("grade", 3)).Modify(dbflex.Inc("grade", 10)), nil)
+			So(err, ShouldBeNil)
+
+			_, err = os.Stat(filepath.Join(workpath, "items.csv"+ext))
+			So(err, ShouldBeNil)
+
+			//-- connection without compression config detects compressed file by its extension
+			plain, _ := dbflex.NewConnectionFromUri(toolkit.Sprintf("text://localhost/%s?extension=csv", workpath),
+				toolkit.M{}.Set("text_obj_setting", NewTextObjSetting(',').SetUseHeader(true)))
+			So(plain.Connect(), ShouldBeNil)
+			So(plain.ObjectNames(dbflex.ObjTypeTable), ShouldResemble, []string{"items"})
+
+			res := []itemModel{}
+			So(plain.Cursor(dbflex.From("items").Select().OrderBy("-grade"), nil).Fetchs(&res, 0), ShouldBeNil)
+			So(len(res), ShouldEqual, 4)
+			So(res[0].ID, ShouldEqual, "Item-3")
+			So(res[0].Grade, ShouldEqual, 13)
+		}
+	})
+}
+
+func TestRecordReader(t *testing.T) {
+	Convey("Read records of text", t, func() {
+		type noteModel struct {
+			ID   string
+			Note string
+		}
+
+		workpath, _ := ioutil.TempDir("", "dbflextext")
+		defer os.RemoveAll(workpath)
+		rcfg := NewTextObjSetting(',').SetUseHeader(true)
+		conn, _ := dbflex.NewConnectionFromUri(toolkit.Sprintf("text://localhost/%s?extension=csv", workpath),
+			toolkit.M{}.Set("text_obj_setting", rcfg))
+		So(conn.Connect(), ShouldBeNil)
+
+		Convey("Quoted field spans lines and has escaped quote", func() {
+			ioutil.WriteFile(filepath.Join(workpath, "notes.csv"),
+				[]byte("ID,Note\r\nN1,\"first line\r\nsecond, \"\"quoted\"\" line\"\r\nN2,plain\r\n"), 0644)
+
+			res := []noteModel{}
+			So(conn.Cursor(dbflex.From("notes").Select(), nil).Fetchs(&res, 0), ShouldBeNil)
+			So(len(res), ShouldEqual, 2)
+			So(res[0].Note, ShouldEqual, "first line\r\nsecond, \"quoted\" line")
+			So(res[1].ID, ShouldEqual, "N2")
+
+			Convey("Rewrite keeps multi line record", func() {
+				_, err := conn.Execute(dbflex.From("notes").Delete().Where(dbflex.Eq("id", "N2")), nil)
+				So(err, ShouldBeNil)
+
+				res := []noteModel{}
+				So(conn.Cursor(dbflex.From("notes").Select(), nil).Fetchs(&res, 0), ShouldBeNil)
+				So(len(res), ShouldEqual, 1)
+				So(res[0].Note, ShouldEqual, "first line\r\nsecond, \"quoted\" line")
+			})
+		})
+
+		Convey("Long line", func() {
+			long := strings.Repeat("x", 100*1024)
+			_, err := conn.Execute(dbflex.From("notes").Insert(), toolkit.M{}.Set("data", &noteModel{"N1", long}))
+			So(err, ShouldBeNil)
+
+			res := []noteModel{}
+			So(conn.Cursor(dbflex.From("notes").Select(), nil).Fetchs(&res, 0), ShouldBeNil)
+			So(res[0].Note, ShouldEqual, long)
+
+			rcfg.SetMaxRecordSize(64 * 1024)
+			defer rcfg.SetMaxRecordSize(0)
+			So(conn.Cursor(dbflex.From("notes").Select(), nil).Fetchs(&res, 0), ShouldNotBeNil)
+		})
+
+		Convey("Strict mode reports line number", func() {
+			ioutil.WriteFile(filepath.Join(workpath, "notes.csv"),
+				[]byte("ID,Note\nN1,\"multi\nline\"\nN2,\"bro\"ken\nN3,O'Brien\n"), 0644)
+
+			res := []noteModel{}
+			So(conn.Cursor(dbflex.From("notes").Select(), nil).Fetchs(&res, 0), ShouldBeNil)
+			So(len(res), ShouldEqual, 3)
+
+			rcfg.SetStrict(true)
+			defer rcfg.SetStrict(false)
+			err := conn.Cursor(dbflex.From("notes").Select(), nil).Fetchs(&res, 0)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "line 4")
+
+			ioutil.WriteFile(filepath.Join(workpath, "notes.csv"), []byte("ID,Note\nN1,O'Brien\nN2, \"it's\"\n"), 0644)
+			So(conn.Cursor(dbflex.From("notes").Select(), nil).Fetchs(&res, 0), ShouldBeNil)
+			So(len(res), ShouldEqual, 2)
+			So(res[0].Note, ShouldEqual, "O'Brien")
+		})
+	})
+}
+
+func TestCount(t *testing.T) {
+	Convey("Count text table", t, func() {
+		type itemModel struct {
+			ID    string
+			Grade int
+		}
+
+		workpath, _ := ioutil.TempDir("", "dbflextext")
+		defer os.RemoveAll(workpath)
+		conn, _ := dbflex.NewConnectionFromUri(toolkit.Sprintf("text://localhost/%s?extension=csv&count_cache=true", workpath),
+			toolkit.M{}.Set("text_obj_setting", NewTextObjSetting(',').SetUseHeader(true).SetSkipLines(1)))
+		So(conn.Connect(), ShouldBeNil)
+
+		for i := 1; i <= 20; i++ {
+			_, err := conn.Execute(dbflex.From("items").Insert(),
+				toolkit.M{}.Set("data", &itemModel{toolkit.Sprintf("Item-%02d", i), i % 4}))
+			So(err, ShouldBeNil)
+		}
+
+		Convey("Count does not affect fetch", func() {
+			cursor := conn.Cursor(dbflex.From("items").Select().Take(5), nil)
+			So(cursor.Count(), ShouldEqual, 20)
+			So(cursor.Error(), ShouldBeNil)
+
+			res := []itemModel{}
+			So(cursor.Fetchs(&res, 0), ShouldBeNil)
+			So(len(res), ShouldEqual, 5)
+			So(cursor.Count(), ShouldEqual, 20)
+		})
+
+		Convey("Count with filter and aggregation", func() {
+			So(conn.Cursor(dbflex.From("items").Select().Where(dbflex.Eq("grade", 1)), nil).Count(), ShouldEqual, 5)
+			So(conn.Cursor(dbflex.From("items").GroupBy("grade").Aggr(dbflex.Count("ID")), nil).Count(), ShouldEqual, 4)
+		})
+
+		Convey("Cached count is reused until file is changed", func() {
+			filePath := filepath.Join(workpath, "items.csv")
+			So(conn.Cursor(dbflex.From("items").Select(), nil).Count(), ShouldEqual, 20)
+
+			//-- same size and modification time keeps the cached count
+			fi, _ := os.Stat(filePath)
+			bs, _ := ioutil.ReadFile(filePath)
+			ioutil.WriteFile(filePath, []byte(strings.Replace(string(bs), "\"Item-20\",0\n", "\"Item-2\",0\n\n", 1)), 0644)
+			os.Chtimes(filePath, fi.ModTime(), fi.ModTime())
+			So(conn.Cursor(dbflex.From("items").Select(), nil).Count(), ShouldEqual, 20)
+
+			_, err := conn.Execute(dbflex.From("items").Insert(), toolkit.M{}.Set("data", &itemModel{"Item-21", 1}))
+			So(err, ShouldBeNil)
+			So(conn.Cursor(dbflex.From("items").Select(), nil).Count(), ShouldEqual, 21)
+
+			res := []itemModel{}
+			So(conn.Cursor(dbflex.From("items").Select(), nil).Fetchs(&res, 0), ShouldBeNil)
+			So(len(res), ShouldEqual, 21)
+		})
+	})
+}
+
+func TestColumnTypes(t *testing.T) {
+	Convey("Column type of map target", t, func() {
+		workpath, _ := ioutil.TempDir("", "dbflextext")
+		defer os.RemoveAll(workpath)
+		conn, _ := dbflex.NewConnectionFromUri(toolkit.Sprintf("text://localhost/%s?extension=csv", workpath),
+			toolkit.M{}.Set("text_obj_setting", NewTextObjSetting(',').SetUseHeader(true)))
+		So(conn.Connect(), ShouldBeNil)
+
+		Convey("Inferred from sampled records", func() {
+			ioutil.WriteFile(filepath.Join(workpath, "items.csv"),
+				[]byte("Code,Amount,Qty\n12,10,1\n007,20.5,2\n30,,3\n"), 0644)
+
+			ms := []toolkit.M{}
+			So(conn.Cursor(dbflex.From("items").Select(), nil).Fetchs(&ms, 0), ShouldBeNil)
+			So(len(ms), ShouldEqual, 3)
+			So(ms[0]["Code"], ShouldEqual, "12")
+			So(ms[1]["Code"], ShouldEqual, "007")
+			So(ms[0]["Amount"], ShouldEqual, float64(10))
+			So(ms[2]["Amount"], ShouldBeNil)
+			So(ms[2]["Qty"], ShouldEqual, 3)
+
+			res := []toolkit.M{}
+			So(conn.Cursor(dbflex.From("items").Select().Where(dbflex.Eq("Code", "007")), nil).Fetchs(&res, 0), ShouldBeNil)
+			So(len(res), ShouldEqual, 1)
+			So(len(conn.(*Connection).columns), ShouldEqual, 1)
+
+			ioutil.WriteFile(filepath.Join(workpath, "items.csv"),
+				[]byte("Code,Amount,Qty\n12,10,1\n007,20.5,2\n30,,3\n40,5,many\n"), 0644)
+			So(conn.Cursor(dbflex.From("items").Select(), nil).Fetchs(&ms, 0), ShouldBeNil)
+			So(ms[0]["Qty"], ShouldEqual, "1")
+		})
+
+		Convey("Declared on schema file", func() {
+			hconn, _ := dbflex.NewConnectionFromUri(toolkit.Sprintf("text://localhost/%s?extension=csv", workpath),
+				toolkit.M{}.Set("text_obj_setting", NewTextObjSetting(',')))
+			So(hconn.Connect(), ShouldBeNil)
+			So(hconn.(*Connection).WriteSchema(&dbflex.TableSchema{Name: "feed", Fields: []*dbflex.FieldSchema{
+				{Name: "ID", GoType: "string"}, {Name: "Qty", GoType: "float64"}}}), ShouldBeNil)
+
+			_, err := hconn.Execute(dbflex.From("feed").Insert(), toolkit.M{}.Set("data", toolkit.M{}.Set("Qty", 5).Set("ID", "0042")))
+			So(err, ShouldBeNil)
+			bs, _ := ioutil.ReadFile(filepath.Join(workpath, "feed.csv"))
+			So(string(bs), ShouldEqual, "\"0042\",5\n")
+
+			ms := []toolkit.M{}
+			So(hconn.Cursor(dbflex.From("feed").Select().Where(dbflex.Eq("ID", "0042")), nil).Fetchs(&ms, 0), ShouldBeNil)
+			So(len(ms), ShouldEqual, 1)
+			So(ms[0]["ID"], ShouldEqual, "0042")
+			So(ms[0]["Qty"], ShouldEqual, float64(5))
+
+			schema, err := hconn.Describe("feed")
+			So(err, ShouldBeNil)
+			So(schema.Fields[1].GoType, ShouldEqual, "float64")
+			So(hconn.ObjectNames(dbflex.ObjTypeTable), ShouldResemble, []string{"feed"})
+		})
+	})
+}
@@ -0,0 +1,97 @@
+package xlsx
+
+import (
+	"path/filepath"
+
+	"github.com/eaciit/toolkit"
+
+	"github.com/eaciit/dbflex"
+)
+
+func init() {
+	//=== sample: xlsx://localhost/usr/local/data/book.xlsx or xlsx://data/book.xlsx
+	dbflex.RegisterDriver("xlsx", func(si *dbflex.ServerInfo) dbflex.IConnection {
+		c := new(Connection)
+		c.ServerInfo = *si
+		c.SetThis(c)
+		c.SetFieldNameTag("json")
+		return c
+	})
+}
+
+// Connection of xlsx driver, each sheet of the workbook is a table. The first row of a sheet is the header
+// and each following row is a record
+type Connection struct {
+	dbflex.ConnectionBase
+
+	wb *workbook
+}
+
+// Connect loads the workbook, it is created on the first write if not exist yet
+func (c *Connection) Connect() error {
+	filePath := c.Database
+	if c.Host != "" && c.Host != "localhost" {
+		filePath = filepath.Join(c.Host, c.Database)
+	}
+	if filePath == "" {
+		return toolkit.Errorf("workbook file is not specified")
+	}
+
+	wb, err := openWorkbook(filePath)
+	if err != nil {
+		return err
+	}
+	c.wb = wb
+	return nil
+}
+
+func (c *Connection) State() string {
+	if c.wb != nil {
+		return dbflex.StateConnected
+	}
+	return dbflex.StateUnknown
+}
+
+func (c *Connection) Close() {
+	if c.wb != nil {
+		closeWorkbook(c.wb)
+		c.wb = nil
+	}
+}
+
+func (c *Connection) NewQuery() dbflex.IQuery {
+	q := new(Query)
+	q.SetThis(q)
+	q.SetConnection(c)
+	return q
+}
+
+func (c *Connection) ObjectNames(dbflex.ObjTypeEnum) []string {
+	if c.wb == nil {
+		return []string{}
+	}
+
+	c.wb.RLock()
+	defer c.wb.RUnlock()
+	return c.wb.sheets()
+}
+
+func (c *Connection) ValidateTable(interface{}, bool) error {
+	return nil
+}
+
+func (c *Connection) DropTable(name string) error {
+	if c.wb == nil {
+		return toolkit.Errorf("connection is not yet established")
+	}
+
+	c.wb.Lock()
+	defer c.wb.Unlock()
+	if !c.wb.hasSheet(name) {
+		return nil
+	}
+	if err := c.wb.dropSheet(name); err != nil {
+		return toolkit.Errorf("unable to drop %s. %s", name, err.Error())
+	}
+	return c.wb.save()
+}
@@ -0,0 +1,308 @@
+package xlsx
+
+import (
+	"strings"
+
+	"github.com/eaciit/toolkit"
+
+	"github.com/eaciit/dbflex"
+	"github.com/eaciit/dbflex/drivers/internal/docutil"
+)
+
+// IDField is key field of a record when data has no Id method
+const IDField = "_id"
+
+type Query struct {
+	dbflex.QueryBase
+}
+
+// BuildFilter returns the filter as is, it is evaluated per record by the cursor
+func (q *Query) BuildFilter(f *dbflex.Filter) (interface{}, error) {
+	return f, nil
+}
+
+func (q *Query) BuildCommand() (interface{}, error) {
+	return nil, nil
+}
+
+func (q *Query) workbook() (*workbook, string, error) {
+	wb := q.Connection().(*Connection).wb
+	if wb == nil {
+		return nil, "", toolkit.Errorf("connection is not yet established")
+	}
+
+	tablename := q.Config(dbflex.ConfigKeyTableName, "").(string)
+	if tablename == "" {
+		return nil, "", toolkit.Errorf("no tablename is specified")
+	}
+	return wb, tablename, nil
+}
+
+// Cursor reads matched records from the loaded workbook when the cursor is created or reset
+func (q *Query) Cursor(toolkit.M) dbflex.ICursor {
+	c := docutil.NewCursor(q)
+	wb, tablename, err := q.workbook()
+	if err != nil {
+		c.SetError(err)
+		return c
+	}
+
+	return c.Load(func(filter *dbflex.Filter) ([]toolkit.M, error) {
+		wb.RLock()
+		defer wb.RUnlock()
+
+		s, err := wb.table(tablename)
+		if err != nil {
+			return nil, err
+		}
+		docs := []toolkit.M{}
+		for _, record := range s.records {
+			if record == nil {
+				continue
+			}
+			match, err := filter.Match(record)
+			if err != nil {
+				return nil, err
+			}
+			if match {
+				docs = append(docs, docutil.CopyOf(record).(toolkit.M))
+			}
+		}
+		return docs, nil
+	})
+}
+
+// Execute runs a command on the sheet and saves the workbook.
+// Insert and save return value of the key if any, update and delete return number of affected records.
+// A command that fails after it changes the sheet reloads the workbook from its file
+func (q *Query) Execute(parm toolkit.M) (result interface{}, err error) {
+	cmdType := q.Config(dbflex.ConfigKeyCommandType, "").(string)
+	wb, tablename, err := q.workbook()
+	if err != nil {
+		return nil, err
+	}
+
+	if cmdType == dbflex.QuerySelect {
+		return nil, toolkit.Errorf("select command should use cursor instead of execute")
+	}
+
+	var (
+		data      toolkit.M
+		fields    []string
+		keyFields = []string{IDField}
+	)
+	if parm != nil && parm.Has("data") {
+		if data, fields, err = docutil.ToOrderedDocument(parm.Get("data")); err != nil {
+			return nil, toolkit.Errorf("unable to serialize data. %s", err.Error())
+		}
+		if modelFields := docutil.ModelKeyFields(parm.Get("data")); modelFields != nil {
+			keyFields = modelFields
+		}
+	}
+
+	wb.Lock()
+	defer wb.Unlock()
+
+	s, err := wb.table(tablename)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := false
+	defer func() {
+		if err != nil && changed {
+			wb.load()
+		}
+	}()
+
+	switch cmdType {
+	case dbflex.QueryInsert, dbflex.QuerySave:
+		if data == nil {
+			return nil, toolkit.Errorf("%s fail, no data", cmdType)
+		}
+
+		rowNumber := len(s.records) + 2
+		if keyFilter := recordKeyFilter(data, keyFields); keyFilter != nil {
+			idx, err := s.find(keyFilter)
+			if err != nil {
+				return nil, err
+			}
+			if idx >= 0 {
+				if cmdType == dbflex.QueryInsert {
+					return nil, toolkit.Errorf("insert fail, duplicate key %s", toolkit.JsonString(keyFilter))
+				}
+				rowNumber = idx + 2
+			}
+			result = keyValue(data, keyFields)
+		}
+
+		changed = true
+		if !wb.hasSheet(tablename) {
+			if err = wb.newSheet(tablename, fields); err != nil {
+				return nil, toolkit.Errorf("unable to create sheet %s. %s", tablename, err.Error())
+			}
+			if s, err = wb.table(tablename); err != nil {
+				return nil, err
+			}
+		} else if err = wb.addColumns(s, fields); err != nil {
+			return nil, toolkit.Errorf("unable to write header of %s. %s", tablename, err.Error())
+		}
+		if err = wb.writeRecord(s, rowNumber, data); err != nil {
+			return nil, err
+		}
+		if err = s.reload(wb, rowNumber); err != nil {
+			return nil, err
+		}
+
+	case dbflex.QueryUpdate:
+		updateItems := docutil.UpdateItems(q)
+		updates := docutil.UpdateValues(data, q.Config("fields", []string{}).([]string), keyFields)
+		if len(updates) == 0 && len(updateItems) == 0 {
+			return nil, toolkit.Errorf("update need to have data or update items")
+		}
+
+		newFields := []string{}
+		for _, field := range fields {
+			if updates.Has(field) {
+				newFields = append(newFields, field)
+			}
+		}
+		for _, item := range updateItems {
+			newFields = append(newFields, strings.Split(item.Field, ".")[0])
+		}
+
+		filter, _ := q.Config(dbflex.ConfigKeyFilter, nil).(*dbflex.Filter)
+		affected := 0
+		for idx, record := range s.records {
+			if record == nil {
+				continue
+			}
+			match, err := filter.Match(record)
+			if err != nil {
+				return nil, err
+			}
+			if !match {
+				continue
+			}
+			m := docutil.CopyOf(record).(toolkit.M)
+			if affected == 0 {
+				changed = true
+				if err = wb.addColumns(s, newFields); err != nil {
+					return nil, toolkit.Errorf("unable to write header of %s. %s", tablename, err.Error())
+				}
+			}
+
+			if err = docutil.Update(m, updates, updateItems); err != nil {
+				return nil, err
+			}
+			if err = wb.writeRecord(s, idx+2, m); err != nil {
+				return nil, err
+			}
+			if err = s.reload(wb, idx+2); err != nil {
+				return nil, err
+			}
+			affected++
+		}
+		if affected == 0 {
+			return 0, nil
+		}
+		result = affected
+
+	case dbflex.QueryDelete:
+		filter, _ := q.Config(dbflex.ConfigKeyFilter, nil).(*dbflex.Filter)
+		rowNumbers := []int{}
+		for idx, m := range s.records {
+			if m == nil {
+				continue
+			}
+			match, err := filter.Match(m)
+			if err != nil {
+				return nil, err
+			}
+			if match {
+				rowNumbers = append(rowNumbers, idx+2)
+			}
+		}
+		if len(rowNumbers) == 0 {
+			return 0, nil
+		}
+
+		// rows are removed from the bottom so number of the remaining rows is not shifted
+		changed = true
+		for i := len(rowNumbers) - 1; i >= 0; i-- {
+			if err = wb.f.RemoveRow(tablename, rowNumbers[i]); err != nil {
+				return nil, toolkit.Errorf("unable to delete row %d of %s. %s", rowNumbers[i], tablename, err.Error())
+			}
+			idx := rowNumbers[i] - 2
+			s.records = append(s.records[:idx], s.records[idx+1:]...)
+		}
+		result = len(rowNumbers)
+
+	default:
+		return nil, toolkit.Errorf("unknown command: %s", cmdType)
+	}
+
+	if err = wb.save(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// reload reads a written row back into the records, so the records keep values as they are stored on the cells
+func (s *sheet) reload(wb *workbook, rowNumber int) error {
+	m, err := wb.readRecord(s, rowNumber)
+	if err != nil {
+		return err
+	}
+	for idx := len(s.records); idx < rowNumber-1; idx++ {
+		s.records = append(s.records, nil)
+	}
+	s.records[rowNumber-2] = m
+	return nil
+}
+
+// find returns index of the first record that match the filter, -1 if none
+func (s *sheet) find(filter *dbflex.Filter) (int, error) {
+	for idx, m := range s.records {
+		if m == nil {
+			continue
+		}
+		match, err := filter.Match(m)
+		if err != nil {
+			return -1, err
+		}
+		if match {
+			return idx, nil
+		}
+	}
+	return -1, nil
+}
+
+// recordKeyFilter returns filter of a record by its key, nil if a key field has no value
+func recordKeyFilter(m toolkit.M, keyFields []string) *dbflex.Filter {
+	filters := []*dbflex.Filter{}
+	for _, field := range keyFields {
+		v, ok := docutil.GetPath(m, field)
+		if !ok || v == nil || v == "" {
+			return nil
+		}
+		filters = append(filters, dbflex.Eq(field, v))
+	}
+	if len(filters) == 1 {
+		return filters[0]
+	}
+	return dbflex.And(filters...)
+}
+
+// keyValue returns value of the key, or values of the key fields for composite key
+func keyValue(m toolkit.M, keyFields []string) interface{} {
+	values := []interface{}{}
+	for _, field := range keyFields {
+		v, _ := docutil.GetPath(m, field)
+		values = append(values, v)
+	}
+	if len(values) == 1 {
+		return values[0]
+	}
+	return values
+}
@@ -0,0 +1,456 @@
+package xlsx
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eaciit/toolkit"
+	"github.com/xuri/excelize/v2"
+
+	"github.com/eaciit/dbflex/drivers/internal/docutil"
+)
+
+// workbook is a xlsx file loaded by the process. It is shared by all connections to the same file,
+// every write is saved back to the file before the command returns
+type workbook struct {
+	sync.RWMutex
+
+	f        *excelize.File
+	filePath string
+	refs     int
+
+	// placeholder is the default sheet of a new workbook, it is replaced by the first table
+	placeholder string
+	date1904    bool
+	dateStyles  map[int]bool
+	stylesMutex sync.Mutex
+
+	// tables keeps sheets that have been read, writes keep them in sync with the cells
+	tables      map[string]*sheet
+	tablesMutex sync.Mutex
+}
+
+var (
+	workbooks      = map[string]*workbook{}
+	workbooksMutex sync.Mutex
+)
+
+// openWorkbook loads a workbook, a new one is prepared if the file does not exist yet
+func openWorkbook(filePath string) (*workbook, error) {
+	workbooksMutex.Lock()
+	defer workbooksMutex.Unlock()
+
+	if wb, ok := workbooks[filePath]; ok {
+		wb.refs++
+		return wb, nil
+	}
+
+	wb := &workbook{filePath: filePath, refs: 1}
+	if err := wb.load(); err != nil {
+		return nil, err
+	}
+	workbooks[filePath] = wb
+	return wb, nil
+}
+
+// load reads the file into the workbook, a new one is prepared if the file does not exist yet.
+// Sheets that have been read are dropped
+func (wb *workbook) load() error {
+	var (
+		f           *excelize.File
+		placeholder string
+		date1904    bool
+	)
+	if _, err := os.Stat(wb.filePath); os.IsNotExist(err) {
+		f = excelize.NewFile()
+		placeholder = f.GetSheetName(0)
+	} else {
+		if f, err = excelize.OpenFile(wb.filePath); err != nil {
+			return toolkit.Errorf("unable to open %s. %s", wb.filePath, err.Error())
+		}
+		if props, err := f.GetWorkbookProps(); err == nil && props.Date1904 != nil {
+			date1904 = *props.Date1904
+		}
+	}
+
+	if wb.f != nil {
+		wb.f.Close()
+	}
+	wb.f, wb.placeholder, wb.date1904 = f, placeholder, date1904
+	wb.stylesMutex.Lock()
+	wb.dateStyles = map[int]bool{}
+	wb.stylesMutex.Unlock()
+	wb.tablesMutex.Lock()
+	wb.tables = map[string]*sheet{}
+	wb.tablesMutex.Unlock()
+	return nil
+}
+
+func closeWorkbook(wb *workbook) {
+	workbooksMutex.Lock()
+	defer workbooksMutex.Unlock()
+
+	wb.refs--
+	if wb.refs <= 0 {
+		wb.f.Close()
+		delete(workbooks, wb.filePath)
+	}
+}
+
+// sheets returns name of sheets, placeholder sheet is excluded
+func (wb *workbook) sheets() []string {
+	names := []string{}
+	for _, name := range wb.f.GetSheetList() {
+		if name != wb.placeholder {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// hasSheet checks whether a sheet exists, sheet name is case insensitive like on Excel
+func (wb *workbook) hasSheet(name string) bool {
+	idx, err := wb.f.GetSheetIndex(name)
+	return err == nil && idx >= 0 && name != wb.placeholder
+}
+
+// newSheet creates a sheet with given header, placeholder sheet of a new workbook is renamed instead
+func (wb *workbook) newSheet(name string, header []string) error {
+	if wb.placeholder != "" {
+		if err := wb.f.SetSheetName(wb.placeholder, name); err != nil {
+			return err
+		}
+		wb.placeholder = ""
+	} else if _, err := wb.f.NewSheet(name); err != nil {
+		return err
+	}
+	if err := wb.f.SetSheetRow(name, "A1", &header); err != nil {
+		return err
+	}
+
+	wb.tablesMutex.Lock()
+	wb.tables[strings.ToLower(name)] = &sheet{name: name, header: header}
+	wb.tablesMutex.Unlock()
+	return nil
+}
+
+// dropSheet removes a sheet. Workbook needs at least one sheet, so the last sheet is replaced by a placeholder
+func (wb *workbook) dropSheet(name string) error {
+	if !wb.hasSheet(name) {
+		return nil
+	}
+	if wb.f.SheetCount == 1 {
+		placeholder := "Sheet1"
+		if strings.EqualFold(name, placeholder) {
+			placeholder = "Sheet2"
+		}
+		if _, err := wb.f.NewSheet(placeholder); err != nil {
+			return err
+		}
+		wb.placeholder = placeholder
+	}
+
+	wb.tablesMutex.Lock()
+	delete(wb.tables, strings.ToLower(name))
+	wb.tablesMutex.Unlock()
+	return wb.f.DeleteSheet(name)
+}
+
+// save writes the workbook into a temp file on the same directory then replaces the original file,
+// so a failed write never leaves a broken workbook
+func (wb *workbook) save() error {
+	tempFile, err := ioutil.TempFile(filepath.Dir(wb.filePath), filepath.Base(wb.filePath)+"_temp_")
+	if err != nil {
+		return toolkit.Errorf("unable to create temp file. %s", err.Error())
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			tempFile.Close()
+			os.Remove(tempFile.Name())
+		}
+	}()
+
+	if _, err = wb.f.WriteTo(tempFile); err != nil {
+		return toolkit.Errorf("unable to write %s. %s", wb.filePath, err.Error())
+	}
+	if err = tempFile.Sync(); err != nil {
+		return toolkit.Errorf("unable to write %s. %s", wb.filePath, err.Error())
+	}
+	if err = tempFile.Close(); err != nil {
+		return toolkit.Errorf("unable to write %s. %s", wb.filePath, err.Error())
+	}
+	if stat, err := os.Stat(wb.filePath); err == nil {
+		os.Chmod(tempFile.Name(), stat.Mode())
+	} else {
+		os.Chmod(tempFile.Name(), 0644)
+	}
+
+	if err = os.Rename(tempFile.Name(), wb.filePath); err != nil {
+		return toolkit.Errorf("unable to replace file %s. %s", wb.filePath, err.Error())
+	}
+	committed = true
+	return nil
+}
+
+// sheet is the header and records of a table. Row number of a record is its index plus 2,
+// the first row is the header
+type sheet struct {
+	name    string
+	header  []string
+	records []toolkit.M
+}
+
+// table returns header and records of a sheet, the sheet is read once and kept by the workbook.
+// Caller should not change the records, except while holding the write lock of the workbook
+func (wb *workbook) table(name string) (*sheet, error) {
+	wb.tablesMutex.Lock()
+	defer wb.tablesMutex.Unlock()
+
+	key := strings.ToLower(name)
+	if s, ok := wb.tables[key]; ok {
+		return s, nil
+	}
+	s, err := wb.readSheet(name)
+	if err != nil {
+		return nil, err
+	}
+	if wb.hasSheet(name) {
+		wb.tables[key] = s
+	}
+	return s, nil
+}
+
+// readSheet reads header and records of a sheet, empty row is read as a nil record so row number is kept
+func (wb *workbook) readSheet(name string) (*sheet, error) {
+	s := &sheet{name: name}
+	if !wb.hasSheet(name) {
+		return s, nil
+	}
+
+	rows, err := wb.f.Rows(name)
+	if err != nil {
+		return nil, toolkit.Errorf("unable to read sheet %s. %s", name, err.Error())
+	}
+	defer rows.Close()
+
+	rowNumber := 0
+	for rows.Next() {
+		rowNumber++
+		values, err := rows.Columns(excelize.Options{RawCellValue: true})
+		if err != nil {
+			return nil, toolkit.Errorf("unable to read sheet %s row %d. %s", name, rowNumber, err.Error())
+		}
+
+		if rowNumber == 1 {
+			for _, v := range values {
+				s.header = append(s.header, strings.TrimSpace(v))
+			}
+			continue
+		}
+
+		m := toolkit.M{}
+		for idx, raw := range values {
+			if idx >= len(s.header) || s.header[idx] == "" || raw == "" {
+				continue
+			}
+			cell, _ := excelize.CoordinatesToCellName(idx+1, rowNumber)
+			if v := wb.cellValue(name, cell, raw); v != nil {
+				m.Set(s.header[idx], v)
+			}
+		}
+		if len(m) == 0 {
+			m = nil
+		}
+		s.records = append(s.records, m)
+	}
+	return s, rows.Error()
+}
+
+// readRecord reads a row of a sheet after it is written, nil if the row is empty
+func (wb *workbook) readRecord(s *sheet, rowNumber int) (toolkit.M, error) {
+	m := toolkit.M{}
+	for idx, name := range s.header {
+		if name == "" {
+			continue
+		}
+		cell, _ := excelize.CoordinatesToCellName(idx+1, rowNumber)
+		raw, err := wb.f.GetCellValue(s.name, cell, excelize.Options{RawCellValue: true})
+		if err != nil {
+			return nil, toolkit.Errorf("unable to read %s!%s. %s", s.name, cell, err.Error())
+		}
+		if raw == "" {
+			continue
+		}
+		if v := wb.cellValue(s.name, cell, raw); v != nil {
+			m.Set(name, v)
+		}
+	}
+	if len(m) == 0 {
+		return nil, nil
+	}
+	return m, nil
+}
+
+// cellValue converts raw value of a cell into its native type. Number with date format becomes time.Time
+func (wb *workbook) cellValue(sheetName, cell, raw string) interface{} {
+	cellType, _ := wb.f.GetCellType(sheetName, cell)
+	switch cellType {
+	case excelize.CellTypeBool:
+		return raw == "1" || strings.EqualFold(raw, "true")
+
+	case excelize.CellTypeDate:
+		if t, err := time.Parse(time.RFC3339Nano, raw); err == nil {
+			return t
+		}
+		return raw
+
+	case excelize.CellTypeUnset, excelize.CellTypeNumber:
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return raw
+		}
+		if wb.isDateCell(sheetName, cell) {
+			if t, err := excelize.ExcelDateToTime(n, wb.date1904); err == nil {
+				return t
+			}
+		}
+		return n
+	}
+	return raw
+}
+
+// isDateCell checks number format of a cell, result is kept per style
+func (wb *workbook) isDateCell(sheetName, cell string) bool {
+	styleID, err := wb.f.GetCellStyle(sheetName, cell)
+	if err != nil || styleID == 0 {
+		return false
+	}
+
+	wb.stylesMutex.Lock()
+	defer wb.stylesMutex.Unlock()
+	if isDate, ok := wb.dateStyles[styleID]; ok {
+		return isDate
+	}
+
+	isDate := false
+	if style, err := wb.f.GetStyle(styleID); err == nil {
+		if style.CustomNumFmt != nil {
+			isDate = isDateFormat(*style.CustomNumFmt)
+		} else {
+			isDate = isDateNumFmt(style.NumFmt)
+		}
+	}
+	wb.dateStyles[styleID] = isDate
+	return isDate
+}
+
+// isDateNumFmt checks built-in number format id of date and time
+func isDateNumFmt(id int) bool {
+	return (id >= 14 && id <= 22) || (id >= 27 && id <= 36) || (id >= 45 && id <= 47) || (id >= 50 && id <= 58)
+}
+
+// isDateFormat checks whether a custom number format has date or time part, quoted text,
+// escaped character and bracket section such as color are ignored
+func isDateFormat(format string) bool {
+	inQuote, inBracket, escaped := false, false, false
+	for _, ch := range strings.ToLower(format) {
+		switch {
+		case escaped:
+			escaped = false
+		case ch == '\\':
+			escaped = true
+		case ch == '"':
+			inQuote = !inQuote
+		case inQuote:
+		case ch == '[':
+			inBracket = true
+		case ch == ']':
+			inBracket = false
+		case inBracket:
+		case strings.ContainsRune("ymdhs", ch):
+			return true
+		}
+	}
+	return false
+}
+
+// column returns index of a field on the header, header is case insensitive
+func (s *sheet) column(field string) int {
+	for idx, name := range s.header {
+		if name == field {
+			return idx
+		}
+	}
+	for idx, name := range s.header {
+		if name != "" && strings.EqualFold(name, field) {
+			return idx
+		}
+	}
+	return -1
+}
+
+// addColumns appends fields that are not on the header yet
+func (wb *workbook) addColumns(s *sheet, fields []string) error {
+	for _, field := range fields {
+		if s.column(field) >= 0 {
+			continue
+		}
+		s.header = append(s.header, field)
+		cell, _ := excelize.CoordinatesToCellName(len(s.header), 1)
+		if err := wb.f.SetCellStr(s.name, cell, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeRecord writes fields of a record into a row, column of the header that is not on the record is cleared
+func (wb *workbook) writeRecord(s *sheet, rowNumber int, m toolkit.M) error {
+	for idx, name := range s.header {
+		if name == "" {
+			continue
+		}
+		var v interface{}
+		if key, ok := docutil.MapKey(m, name); ok {
+			v = m[key]
+		}
+
+		cell, _ := excelize.CoordinatesToCellName(idx+1, rowNumber)
+		if err := wb.writeCell(s.name, cell, v); err != nil {
+			return toolkit.Errorf("unable to write %s!%s. %s", s.name, cell, err.Error())
+		}
+	}
+	return nil
+}
+
+// writeCell writes a value with its native cell type, document and array are written as json text
+func (wb *workbook) writeCell(sheetName, cell string, v interface{}) error {
+	switch tv := v.(type) {
+	case nil:
+		return wb.f.SetCellValue(sheetName, cell, nil)
+	case time.Time:
+		return wb.f.SetCellValue(sheetName, cell, tv.UTC())
+	case string:
+		return wb.f.SetCellStr(sheetName, cell, tv)
+	case bool:
+		return wb.f.SetCellBool(sheetName, cell, tv)
+	}
+
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+		bs, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		return wb.f.SetCellStr(sheetName, cell, string(bs))
+	}
+	return wb.f.SetCellValue(sheetName, cell, v)
+}
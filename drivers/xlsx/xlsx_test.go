@@ -0,0 +1,180 @@
+package xlsx
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/eaciit/toolkit"
+	"github.com/xuri/excelize/v2"
+
+	"github.com/eaciit/dbflex"
+	"github.com/eaciit/dbflex/orm"
+	"github.com/eaciit/dbflex/testbase"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCRUD(t *testing.T) {
+	workpath, _ := ioutil.TempDir("", "dbflexxlsx")
+	defer os.RemoveAll(workpath)
+
+	crud := testbase.NewCRUD(t, toolkit.Sprintf("xlsx://localhost/%s", filepath.Join(workpath, "crud.xlsx")), 1000, nil)
+	crud.RunTest()
+}
+
+type product struct {
+	orm.DatamodelBase `json:"-"`
+	Code              string    `json:"Code"`
+	Name              string    `json:"Name"`
+	Price             float64   `json:"Price"`
+	Active            bool      `json:"Active"`
+	Since             time.Time `json:"Since"`
+}
+
+func (p *product) TableName() string {
+	return "Products"
+}
+
+func (p *product) Id() ([]string, []interface{}) {
+	return []string{"Code"}, []interface{}{p.Code}
+}
+
+// writeBook prepares a workbook the way a user does on Excel, date cell is a number with date format
+func writeBook(filePath string) error {
+	f := excelize.NewFile()
+	defer f.Close()
+	f.SetSheetName("Sheet1", "Products")
+
+	dateStyle, err := f.NewStyle(&excelize.Style{NumFmt: 14})
+	if err != nil {
+		return err
+	}
+	f.SetSheetRow("Products", "A1", &[]interface{}{"Code", "Name", "Price", "Active", "Since"})
+	for i := 1; i <= 5; i++ {
+		row := i + 1
+		f.SetCellStr("Products", toolkit.Sprintf("A%d", row), toolkit.Sprintf("P%d", i))
+		f.SetCellStr("Products", toolkit.Sprintf("B%d", row), toolkit.Sprintf("Product %d", i))
+		f.SetCellFloat("Products", toolkit.Sprintf("C%d", row), float64(i)*1.5, -1, 64)
+		f.SetCellBool("Products", toolkit.Sprintf("D%d", row), i%2 == 0)
+		f.SetCellValue("Products", toolkit.Sprintf("E%d", row), 43831+i)
+		f.SetCellStyle("Products", toolkit.Sprintf("E%d", row), toolkit.Sprintf("E%d", row), dateStyle)
+	}
+	return f.SaveAs(filePath)
+}
+
+func TestCommands(t *testing.T) {
+	workpath, _ := ioutil.TempDir("", "dbflexxlsx")
+	defer os.RemoveAll(workpath)
+	filePath := filepath.Join(workpath, "book.xlsx")
+	connTxt := toolkit.Sprintf("xlsx://localhost/%s", filePath)
+
+	Convey("Xlsx commands", t, func() {
+		So(writeBook(filePath), ShouldBeNil)
+		conn, err := dbflex.NewConnectionFromUri(connTxt, nil)
+		So(err, ShouldBeNil)
+		So(conn.Connect(), ShouldBeNil)
+		defer conn.Close()
+
+		Convey("Cells have native type", func() {
+			So(conn.ObjectNames(dbflex.ObjTypeTable), ShouldResemble, []string{"Products"})
+
+			ms := []toolkit.M{}
+			So(conn.Cursor(dbflex.From("Products").Select().OrderBy("code"), nil).Fetchs(&ms, 0), ShouldBeNil)
+			So(len(ms), ShouldEqual, 5)
+			So(ms[0].Get("Code"), ShouldEqual, "P1")
+			So(ms[0].Get("Price"), ShouldEqual, 1.5)
+			So(ms[1].Get("Active"), ShouldEqual, true)
+			So(ms[0].Get("Since"), ShouldHaveSameTypeAs, time.Time{})
+			So(ms[0].Get("Since").(time.Time).Format("2006-01-02"), ShouldEqual, "2020-01-02")
+		})
+
+		Convey("Filter, order and aggregate", func() {
+			products := []product{}
+			cur := conn.Cursor(dbflex.From("Products").Select().
+				Where(dbflex.And(dbflex.Eq("active", true), dbflex.Gt("since", time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)))).
+				OrderBy("-price"), nil)
+			So(cur.Fetchs(&products, 0), ShouldBeNil)
+			So(len(products), ShouldEqual, 2)
+			So(products[0].Code, ShouldEqual, "P4")
+			So(products[1].Code, ShouldEqual, "P2")
+
+			ms := []toolkit.M{}
+			So(conn.Cursor(dbflex.From("Products").Aggr(dbflex.Sum("price")).GroupBy("active"), nil).Fetchs(&ms, 0), ShouldBeNil)
+			So(len(ms), ShouldEqual, 2)
+		})
+
+		Convey("Writes go back to the workbook", func() {
+			_, err := conn.Execute(dbflex.From("Products").Insert(), toolkit.M{}.Set("data",
+				toolkit.M{}.Set("Code", "P6").Set("Name", "Product 6").Set("Price", 9).Set("Stock", 3)))
+			So(err, ShouldBeNil)
+
+			n, err := conn.Execute(dbflex.From("Products").Where(dbflex.Eq("code", "P1")).
+				Modify(dbflex.Inc("price", 1)), nil)
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 1)
+
+			n, err = conn.Execute(dbflex.From("Products").Where(dbflex.Eq("active", false)).Delete(), nil)
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 3)
+
+			f, err := excelize.OpenFile(filePath)
+			So(err, ShouldBeNil)
+			defer f.Close()
+			rows, err := f.GetRows("Products", excelize.Options{RawCellValue: true})
+			So(err, ShouldBeNil)
+			So(rows[0], ShouldResemble, []string{"Code", "Name", "Price", "Active", "Since", "Stock"})
+			So(len(rows), ShouldEqual, 4)
+			So(rows[3][0], ShouldEqual, "P6")
+			So(rows[3][5], ShouldEqual, "3")
+			cellType, _ := f.GetCellType("Products", "D2")
+			So(cellType, ShouldEqual, excelize.CellTypeBool)
+		})
+
+		Convey("Failed command leaves the workbook unchanged", func() {
+			_, err := conn.Execute(dbflex.From("Products").Where(dbflex.Eq("code", "P1")).
+				Modify(dbflex.Set("Note", "n"), dbflex.Inc("name", 1)), nil)
+			So(err, ShouldNotBeNil)
+
+			_, err = conn.Execute(dbflex.From("Products").Where(dbflex.Eq("code", "P5")).Delete(), nil)
+			So(err, ShouldBeNil)
+
+			f, err := excelize.OpenFile(filePath)
+			So(err, ShouldBeNil)
+			defer f.Close()
+			rows, err := f.GetRows("Products")
+			So(err, ShouldBeNil)
+			So(rows[0], ShouldResemble, []string{"Code", "Name", "Price", "Active", "Since"})
+			So(len(rows), ShouldEqual, 5)
+		})
+
+		Convey("Model keyed by its Id", func() {
+			p := &product{Code: "P2"}
+			So(orm.Get(conn, p), ShouldBeNil)
+			So(p.Name, ShouldEqual, "Product 2")
+
+			p.Price = 20
+			So(orm.Save(conn, p), ShouldBeNil)
+			_, err := conn.Execute(dbflex.From("Products").Insert(), toolkit.M{}.Set("data", p))
+			So(err, ShouldNotBeNil)
+
+			got := &product{Code: "P2"}
+			So(orm.Get(conn, got), ShouldBeNil)
+			So(got.Price, ShouldEqual, 20)
+			So(got.Since.Equal(p.Since), ShouldBeTrue)
+
+			So(orm.Delete(conn, got), ShouldBeNil)
+			So(orm.Get(conn, &product{Code: "P2"}), ShouldNotBeNil)
+		})
+
+		Convey("New sheet and drop", func() {
+			_, err := conn.Execute(dbflex.From("Orders").Insert(), toolkit.M{}.Set("data", toolkit.M{}.Set("_id", "O1").Set("Qty", 2)))
+			So(err, ShouldBeNil)
+			So(conn.ObjectNames(dbflex.ObjTypeTable), ShouldResemble, []string{"Products", "Orders"})
+
+			So(conn.DropTable("Orders"), ShouldBeNil)
+			So(conn.ObjectNames(dbflex.ObjTypeTable), ShouldResemble, []string{"Products"})
+		})
+	})
+}
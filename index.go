@@ -0,0 +1,36 @@
+package dbflex
+
+import "time"
+
+// Index describes a table index. Field name prefixed by - is indexed in descending order
+type Index struct {
+	Name   string
+	Fields []string
+	Unique bool
+	Text   bool
+
+	// TTL removes record after given duration of its indexed date field. Only supported by mongodb
+	TTL time.Duration
+}
+
+func NewIndex(name string, fields ...string) *Index {
+	idx := new(Index)
+	idx.Name = name
+	idx.Fields = fields
+	return idx
+}
+
+func (idx *Index) SetUnique(unique bool) *Index {
+	idx.Unique = unique
+	return idx
+}
+
+func (idx *Index) SetText(text bool) *Index {
+	idx.Text = text
+	return idx
+}
+
+func (idx *Index) SetTTL(ttl time.Duration) *Index {
+	idx.TTL = ttl
+	return idx
+}
@@ -0,0 +1,434 @@
+package dbflex
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eaciit/toolkit"
+)
+
+// MatchOptions controls how a filter is evaluated in process. Zero value follows mongodb semantic:
+// field name is case insensitive, comparison is case sensitive and contains, startwith and endwith are not
+type MatchOptions struct {
+	// CaseSensitiveField makes field name lookup case sensitive
+	CaseSensitiveField bool
+
+	// IgnoreCase compares text case insensitively on eq, ne, gt, gte, lt, lte, range, in and nin
+	IgnoreCase bool
+
+	// CaseSensitiveText makes contains, startwith and endwith case sensitive
+	CaseSensitiveText bool
+
+	// FieldNameTag is struct tag used as field name, json tag is used if it is not defined
+	FieldNameTag string
+}
+
+// TimeFormats are layouts used to read a text as time when it is compared with a time.Time
+var TimeFormats = []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"}
+
+var defaultMatchOptions = new(MatchOptions)
+
+// Match reports whether obj satisfies the filter. obj could be a toolkit.M, a map with string key or a struct,
+// and field of the filter is a dot path into it. An empty filter matches everything
+func (f *Filter) Match(obj interface{}) (bool, error) {
+	return f.MatchWith(obj, nil)
+}
+
+// MatchWith is Match with options
+func (f *Filter) MatchWith(obj interface{}, opts *MatchOptions) (bool, error) {
+	if f == nil || f.Op == "" {
+		return true, nil
+	}
+	if opts == nil {
+		opts = defaultMatchOptions
+	}
+
+	switch f.Op {
+	case OpAnd:
+		for _, item := range f.Items {
+			if ok, err := item.MatchWith(obj, opts); err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+
+	case OpOr:
+		for _, item := range f.Items {
+			if ok, err := item.MatchWith(obj, opts); err != nil || ok {
+				return ok, err
+			}
+		}
+		return len(f.Items) == 0, nil
+	}
+
+	v, _ := PathValue(obj, f.Field, opts)
+	if values, ok := v.([]interface{}); ok {
+		//-- array field matches if any of its element matches, or all of them for negative op
+		negative := f.Op == OpNe || f.Op == OpNin
+		for _, value := range values {
+			match, err := f.matchValue(value, opts)
+			if err != nil {
+				return false, err
+			}
+			if match != negative {
+				return match, nil
+			}
+		}
+		return negative, nil
+	}
+	return f.matchValue(v, opts)
+}
+
+func (f *Filter) matchValue(v interface{}, opts *MatchOptions) (bool, error) {
+	switch f.Op {
+	case OpEq:
+		return CompareValue(v, f.Value, opts) == 0, nil
+
+	case OpNe:
+		return CompareValue(v, f.Value, opts) != 0, nil
+
+	case OpGt:
+		return v != nil && CompareValue(v, f.Value, opts) > 0, nil
+
+	case OpGte:
+		return v != nil && CompareValue(v, f.Value, opts) >= 0, nil
+
+	case OpLt:
+		return v != nil && CompareValue(v, f.Value, opts) < 0, nil
+
+	case OpLte:
+		return v != nil && CompareValue(v, f.Value, opts) <= 0, nil
+
+	case OpRange:
+		values := toInterfaces(f.Value)
+		if len(values) != 2 {
+			return false, toolkit.Errorf("range filter of %s need 2 values", f.Field)
+		}
+		return v != nil && CompareValue(v, values[0], opts) >= 0 && CompareValue(v, values[1], opts) <= 0, nil
+
+	case OpIn, OpNin:
+		if f.Value != nil && !isArray(reflect.ValueOf(f.Value)) {
+			return false, toolkit.Errorf("%s filter of %s need array value", f.Op, f.Field)
+		}
+		found := false
+		for _, value := range toInterfaces(f.Value) {
+			if CompareValue(v, value, opts) == 0 {
+				found = true
+				break
+			}
+		}
+		return found == (f.Op == OpIn), nil
+
+	case OpContains:
+		var values []string
+		switch fv := f.Value.(type) {
+		case []string:
+			values = fv
+		case string:
+			values = []string{fv}
+		default:
+			return false, toolkit.Errorf("contains filter of %s need string array value", f.Field)
+		}
+		if v == nil {
+			return false, nil
+		}
+		txt := opts.text(toolkit.ToString(v))
+		for _, value := range values {
+			if strings.Contains(txt, opts.text(value)) {
+				return true, nil
+			}
+		}
+		return false, nil
+
+	case OpStartWith:
+		return v != nil && strings.HasPrefix(opts.text(toolkit.ToString(v)), opts.text(toolkit.ToString(f.Value))), nil
+
+	case OpEndWith:
+		return v != nil && strings.HasSuffix(opts.text(toolkit.ToString(v)), opts.text(toolkit.ToString(f.Value))), nil
+	}
+
+	return false, toolkit.Errorf("Filter Op %s is not defined", f.Op)
+}
+
+func (o *MatchOptions) text(s string) string {
+	if o.CaseSensitiveText {
+		return s
+	}
+	return strings.ToLower(s)
+}
+
+// CompareValue returns -1, 0 or 1. nil is less than any other value. A time is compared with a time or a text
+// on one of TimeFormats, a number is compared with a number or a numeric text, a bool is compared with a bool
+// or a text of bool, others are compared as text
+func CompareValue(a, b interface{}, opts *MatchOptions) int {
+	if opts == nil {
+		opts = defaultMatchOptions
+	}
+	a, b = indirect(a), indirect(b)
+	if a == nil || b == nil {
+		if a == nil && b == nil {
+			return 0
+		} else if a == nil {
+			return -1
+		}
+		return 1
+	}
+
+	_, aIsTime := a.(time.Time)
+	_, bIsTime := b.(time.Time)
+	if aIsTime || bIsTime {
+		ta, aOk := toTime(a)
+		tb, bOk := toTime(b)
+		if aOk && bOk {
+			if ta.Before(tb) {
+				return -1
+			} else if ta.After(tb) {
+				return 1
+			}
+			return 0
+		}
+	}
+
+	_, aIsText := a.(string)
+	_, bIsText := b.(string)
+	if !aIsText || !bIsText {
+		if ia, ok := toInt(a); ok {
+			if ib, ok := toInt(b); ok {
+				return compareOrdered(ia < ib, ia > ib)
+			}
+		}
+		fa, aOk := toNumber(a)
+		fb, bOk := toNumber(b)
+		if aOk && bOk {
+			return compareOrdered(fa < fb, fa > fb)
+		}
+
+		ba, aOk := toBool(a)
+		bb, bOk := toBool(b)
+		if aOk && bOk {
+			return compareOrdered(!ba && bb, ba && !bb)
+		}
+	}
+
+	sa, sb := toolkit.ToString(a), toolkit.ToString(b)
+	if opts.IgnoreCase {
+		sa, sb = strings.ToLower(sa), strings.ToLower(sb)
+	}
+	return strings.Compare(sa, sb)
+}
+
+// NumberValue returns v as float64 if it is a number. Unlike CompareValue, a numeric text is not read as number
+func NumberValue(v interface{}) (float64, bool) {
+	return toFloat(reflect.ValueOf(indirect(v)))
+}
+
+func compareOrdered(less, greater bool) int {
+	if less {
+		return -1
+	} else if greater {
+		return 1
+	}
+	return 0
+}
+
+func indirect(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil
+	}
+	return rv.Interface()
+}
+
+func toTime(v interface{}) (time.Time, bool) {
+	switch tv := v.(type) {
+	case time.Time:
+		return tv, true
+	case string:
+		for _, layout := range TimeFormats {
+			if t, err := time.Parse(layout, strings.TrimSpace(tv)); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+func toInt(v interface{}) (int64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), true
+	case reflect.String:
+		i, err := strconv.ParseInt(strings.TrimSpace(rv.String()), 10, 64)
+		return i, err == nil
+	}
+	return 0, false
+}
+
+func toNumber(v interface{}) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String:
+		f, err := strconv.ParseFloat(strings.TrimSpace(rv.String()), 64)
+		return f, err == nil
+	}
+	return toFloat(rv)
+}
+
+func toBool(v interface{}) (bool, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return rv.Bool(), true
+	case reflect.String:
+		b, err := strconv.ParseBool(strings.TrimSpace(rv.String()))
+		return b, err == nil
+	}
+	return false, false
+}
+
+func isArray(rv reflect.Value) bool {
+	return (rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8) || rv.Kind() == reflect.Array
+}
+
+func toInterfaces(v interface{}) []interface{} {
+	if values, ok := v.([]interface{}); ok {
+		return values
+	}
+	return toSlice(v)
+}
+
+// PathValue returns value of a dot path field of obj. A number on the path is used as array index,
+// other name on an array collects the field of each element. An array is returned as []interface{}
+func PathValue(obj interface{}, path string, opts *MatchOptions) (interface{}, bool) {
+	if opts == nil {
+		opts = defaultMatchOptions
+	}
+
+	current := reflect.ValueOf(obj)
+	for _, part := range strings.Split(path, ".") {
+		next, ok := opts.child(current, part)
+		if !ok {
+			return nil, false
+		}
+		current = next
+	}
+
+	current = indirectValue(current)
+	if !current.IsValid() {
+		return nil, true
+	}
+	if isArray(current) {
+		values := make([]interface{}, current.Len())
+		for i := 0; i < current.Len(); i++ {
+			values[i] = indirect(current.Index(i).Interface())
+		}
+		return values, true
+	}
+	return current.Interface(), true
+}
+
+func indirectValue(rv reflect.Value) reflect.Value {
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return reflect.Value{}
+		}
+		rv = rv.Elem()
+	}
+	return rv
+}
+
+func (o *MatchOptions) child(rv reflect.Value, name string) (reflect.Value, bool) {
+	rv = indirectValue(rv)
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return reflect.Value{}, false
+		}
+		if v := rv.MapIndex(reflect.ValueOf(name).Convert(rv.Type().Key())); v.IsValid() {
+			return v, true
+		}
+		if !o.CaseSensitiveField {
+			for _, k := range rv.MapKeys() {
+				if strings.EqualFold(k.String(), name) {
+					return rv.MapIndex(k), true
+				}
+			}
+		}
+
+	case reflect.Struct:
+		return o.structField(rv, name)
+
+	case reflect.Slice, reflect.Array:
+		if idx, err := strconv.Atoi(name); err == nil {
+			if idx < 0 || idx >= rv.Len() {
+				return reflect.Value{}, false
+			}
+			return rv.Index(idx), true
+		}
+
+		//-- collect the field of each element
+		values := []interface{}{}
+		for i := 0; i < rv.Len(); i++ {
+			v, ok := o.child(rv.Index(i), name)
+			if !ok {
+				continue
+			}
+			v = indirectValue(v)
+			if isArray(v) {
+				for j := 0; j < v.Len(); j++ {
+					values = append(values, v.Index(j).Interface())
+				}
+			} else if v.IsValid() {
+				values = append(values, v.Interface())
+			}
+		}
+		return reflect.ValueOf(values), len(values) > 0
+	}
+	return reflect.Value{}, false
+}
+
+func (o *MatchOptions) structField(rv reflect.Value, name string) (reflect.Value, bool) {
+	tag := o.FieldNameTag
+	if tag == "" {
+		tag = "json"
+	}
+
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		fieldName := field.Name
+		if tagName := strings.Split(field.Tag.Get(tag), ",")[0]; tagName != "" && tagName != "-" {
+			fieldName = tagName
+		}
+		if fieldName == name || (!o.CaseSensitiveField && strings.EqualFold(fieldName, name)) ||
+			(fieldName != field.Name && strings.EqualFold(field.Name, name) && !o.CaseSensitiveField) {
+			if field.PkgPath != "" {
+				continue
+			}
+			return rv.Field(i), true
+		}
+	}
+
+	//-- fields of embedded struct are promoted
+	for i := 0; i < rt.NumField(); i++ {
+		if rt.Field(i).Anonymous {
+			if v, ok := o.child(rv.Field(i), name); ok && v.CanInterface() {
+				return v, true
+			}
+		}
+	}
+	return reflect.Value{}, false
+}
@@ -0,0 +1,117 @@
+package dbflex
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eaciit/toolkit"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type matchAddress struct {
+	City string `json:"city"`
+	Zip  string
+}
+
+type matchBase struct {
+	Code string
+}
+
+type matchPerson struct {
+	matchBase
+	Name     string
+	Age      int
+	Salary   float64
+	Active   bool
+	Joined   time.Time
+	Address  *matchAddress
+	Tags     []string
+	Children []matchAddress `json:"kids"`
+}
+
+func TestFilterMatch(t *testing.T) {
+	Convey("Filter match", t, func() {
+		joined := time.Date(2018, 6, 15, 10, 0, 0, 0, time.UTC)
+		p := &matchPerson{matchBase{"P01"}, "Arief", 30, 2500.5, true, joined, &matchAddress{"Jakarta", "10110"},
+			[]string{"admin", "dev"}, []matchAddress{{"Bandung", "40111"}, {"Bogor", "16111"}}}
+		m := toolkit.M{}.Set("name", "Arief").Set("age", 30).Set("joined", joined).
+			Set("address", toolkit.M{}.Set("city", "Jakarta")).
+			Set("tags", []interface{}{"admin", "dev"})
+
+		match := func(f *Filter, obj interface{}) bool {
+			ok, err := f.Match(obj)
+			So(err, ShouldBeNil)
+			return ok
+		}
+
+		Convey("Every op on a struct and a map", func() {
+			for _, obj := range []interface{}{p, m} {
+				So(match(Eq("name", "Arief"), obj), ShouldBeTrue)
+				So(match(Ne("name", "Arief"), obj), ShouldBeFalse)
+				So(match(Gt("age", 29), obj), ShouldBeTrue)
+				So(match(Gte("age", 30), obj), ShouldBeTrue)
+				So(match(Lt("age", 30), obj), ShouldBeFalse)
+				So(match(Lte("age", 30.0), obj), ShouldBeTrue)
+				So(match(Range("age", 20, 40), obj), ShouldBeTrue)
+				So(match(In("age", 10, 30), obj), ShouldBeTrue)
+				So(match(Nin("age", 10, 30), obj), ShouldBeFalse)
+				So(match(Contains("name", "rie", "xyz"), obj), ShouldBeTrue)
+				So(match(StartWith("name", "ar"), obj), ShouldBeTrue)
+				So(match(EndWith("name", "EF"), obj), ShouldBeTrue)
+				So(match(And(Eq("age", 30), Or(Eq("name", "x"), Eq("address.city", "Jakarta"))), obj), ShouldBeTrue)
+				So(match(Eq("missing", nil), obj), ShouldBeTrue)
+				So(match(Gt("missing", 0), obj), ShouldBeFalse)
+			}
+			So(match(new(Filter), p), ShouldBeTrue)
+		})
+
+		Convey("Type coercion", func() {
+			So(match(Eq("age", "30"), p), ShouldBeTrue)
+			So(match(Gt("salary", "2500"), p), ShouldBeTrue)
+			So(match(Eq("active", "true"), p), ShouldBeTrue)
+			So(match(Eq("joined", "2018-06-15T10:00:00Z"), p), ShouldBeTrue)
+			So(match(Lt("joined", "2018-06-16"), p), ShouldBeTrue)
+			So(match(Gt("joined", joined.Add(-time.Hour)), toolkit.M{}.Set("joined", "2018-06-15 10:00:00")), ShouldBeTrue)
+			So(CompareValue("10", "9", nil), ShouldBeLessThan, 0)
+			So(CompareValue(10, "9", nil), ShouldBeGreaterThan, 0)
+
+			n, ok := NumberValue(&p.Salary)
+			So(ok, ShouldBeTrue)
+			So(n, ShouldEqual, p.Salary)
+			_, ok = NumberValue("10")
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("Case sensitivity", func() {
+			So(match(Eq("name", "arief"), p), ShouldBeFalse)
+			ok, _ := Eq("name", "arief").MatchWith(p, &MatchOptions{IgnoreCase: true})
+			So(ok, ShouldBeTrue)
+			ok, _ = Contains("name", "ARIEF").MatchWith(p, &MatchOptions{CaseSensitiveText: true})
+			So(ok, ShouldBeFalse)
+			ok, _ = Eq("Name", "Arief").MatchWith(m, &MatchOptions{CaseSensitiveField: true})
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("Dot path and array", func() {
+			So(match(Eq("address.zip", "10110"), p), ShouldBeTrue)
+			So(match(Eq("code", "P01"), p), ShouldBeTrue)
+			So(match(Eq("tags", "dev"), p), ShouldBeTrue)
+			So(match(Eq("tags.1", "dev"), m), ShouldBeTrue)
+			So(match(Ne("tags", "dev"), p), ShouldBeFalse)
+			So(match(Eq("kids.city", "Bogor"), p), ShouldBeTrue)
+			So(match(Eq("kids.0.city", "Bogor"), p), ShouldBeFalse)
+
+			v, ok := PathValue(p, "kids.city", nil)
+			So(ok, ShouldBeTrue)
+			So(v, ShouldResemble, []interface{}{"Bandung", "Bogor"})
+		})
+
+		Convey("Invalid filter", func() {
+			_, err := NewFilter("age", OpRange, []interface{}{1}, nil).Match(p)
+			So(err, ShouldNotBeNil)
+			_, err = NewFilter("age", "$unknown", 1, nil).Match(p)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
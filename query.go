@@ -142,6 +142,8 @@ func buildGroupedQueryItems(cmd ICommand, b IQuery) error {
 		if len(fields) > 0 {
 			b.This().SetConfig("fields", fields)
 		}
+	} else if _, ok = groupeditems[QueryModify]; ok {
+		b.This().SetConfig(ConfigKeyCommandType, QueryUpdate)
 	} else if _, ok = groupeditems[QueryDelete]; ok {
 		b.This().SetConfig(ConfigKeyCommandType, QueryDelete)
 	} else if _, ok = groupeditems[QuerySave]; ok {
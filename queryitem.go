@@ -8,6 +8,7 @@ const (
 	QueryOrder            = "ORDERBY"
 	QueryInsert           = "INSERT"
 	QueryUpdate           = "UPDATE"
+	QueryModify           = "MODIFY"
 	QueryDelete           = "DELETE"
 	QuerySave             = "SAVE"
 	QueryCommand          = "COMMAND"
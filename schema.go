@@ -0,0 +1,42 @@
+package dbflex
+
+// FieldSchema describes a single field of a table
+type FieldSchema struct {
+	Name       string
+	NativeType string
+	GoType     string
+	Nullable   bool
+	Default    interface{}
+	Key        bool
+	Indexes    []string
+}
+
+// TableSchema describes fields of a table, it is returned by IConnection.Describe
+type TableSchema struct {
+	Name   string
+	Fields []*FieldSchema
+}
+
+// Field returns field schema by its name, it returns nil if field could not be found
+func (t *TableSchema) Field(name string) *FieldSchema {
+	for _, f := range t.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// SetIndexes fills index membership of each field from given indexes
+func (t *TableSchema) SetIndexes(indexes []*Index) {
+	for _, index := range indexes {
+		for _, field := range index.Fields {
+			if len(field) > 0 && field[0] == '-' {
+				field = field[1:]
+			}
+			if f := t.Field(field); f != nil {
+				f.Indexes = append(f.Indexes, index.Name)
+			}
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package dbflex
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+type UpdateOp string
+
+const (
+	UpdateSet         UpdateOp = "$set"
+	UpdateInc                  = "$inc"
+	UpdateMul                  = "$mul"
+	UpdateCurrentDate          = "$currentDate"
+	UpdateUnset                = "$unset"
+	UpdatePush                 = "$push"
+	UpdatePull                 = "$pull"
+)
+
+// UpdateItem is a single update expression, to be used with ICommand.Modify
+type UpdateItem struct {
+	Field string
+	Op    UpdateOp
+	Value interface{}
+}
+
+func NewUpdateItem(field string, op UpdateOp, value interface{}) *UpdateItem {
+	u := new(UpdateItem)
+	u.Field = field
+	u.Op = op
+	u.Value = value
+	return u
+}
+
+// Set overwrites field with value
+func Set(field string, v interface{}) *UpdateItem {
+	return NewUpdateItem(field, UpdateSet, v)
+}
+
+// Inc increments field by v
+func Inc(field string, v interface{}) *UpdateItem {
+	return NewUpdateItem(field, UpdateInc, v)
+}
+
+// Dec decrements field by v, v should be a number
+func Dec(field string, v interface{}) *UpdateItem {
+	return NewUpdateItem(field, UpdateInc, negate(v))
+}
+
+// Mul multiplies field by v
+func Mul(field string, v interface{}) *UpdateItem {
+	return NewUpdateItem(field, UpdateMul, v)
+}
+
+// CurrentDate sets field to current timestamp
+func CurrentDate(field string) *UpdateItem {
+	return NewUpdateItem(field, UpdateCurrentDate, true)
+}
+
+// Unset removes field, or set it to null for driver that has fixed schema
+func Unset(field string) *UpdateItem {
+	return NewUpdateItem(field, UpdateUnset, nil)
+}
+
+// Push appends values into an array field
+func Push(field string, values ...interface{}) *UpdateItem {
+	return NewUpdateItem(field, UpdatePush, values)
+}
+
+// Pull removes values from an array field
+func Pull(field string, values ...interface{}) *UpdateItem {
+	return NewUpdateItem(field, UpdatePull, values)
+}
+
+func negate(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		nv := reflect.New(rv.Type()).Elem()
+		nv.SetInt(-rv.Int())
+		return nv.Interface()
+	case reflect.Float32, reflect.Float64:
+		nv := reflect.New(rv.Type()).Elem()
+		nv.SetFloat(-rv.Float())
+		return nv.Interface()
+	}
+	return v
+}
+
+// Apply returns new value of a field after update item is applied to its current value.
+// It is used by drivers that have no native update expression
+func (u *UpdateItem) Apply(current interface{}) (interface{}, error) {
+	switch u.Op {
+	case UpdateSet:
+		return u.Value, nil
+
+	case UpdateUnset:
+		return nil, nil
+
+	case UpdateCurrentDate:
+		return time.Now(), nil
+
+	case UpdateInc, UpdateMul:
+		if current == nil {
+			if u.Op == UpdateMul {
+				return 0, nil
+			}
+			return u.Value, nil
+		}
+		return arithmetic(current, u.Value, u.Op)
+
+	case UpdatePush:
+		values, _ := u.Value.([]interface{})
+		return append(toSlice(current), values...), nil
+
+	case UpdatePull:
+		values, _ := u.Value.([]interface{})
+		result := []interface{}{}
+		for _, item := range toSlice(current) {
+			found := false
+			for _, value := range values {
+				if equalValue(item, value) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				result = append(result, item)
+			}
+		}
+		return result, nil
+	}
+	return nil, fmt.Errorf("Update Op %s is not defined", u.Op)
+}
+
+func arithmetic(current, value interface{}, op UpdateOp) (interface{}, error) {
+	cv := reflect.ValueOf(current)
+	vv := reflect.ValueOf(value)
+	if isInt(cv) && isInt(vv) {
+		result := cv.Int() + vv.Int()
+		if op == UpdateMul {
+			result = cv.Int() * vv.Int()
+		}
+		nv := reflect.New(cv.Type()).Elem()
+		nv.SetInt(result)
+		return nv.Interface(), nil
+	}
+
+	cf, cok := toFloat(cv)
+	vf, vok := toFloat(vv)
+	if !cok || !vok {
+		return nil, fmt.Errorf("unable to apply %s, %v and %v should be a number", op, current, value)
+	}
+	if op == UpdateMul {
+		return cf * vf, nil
+	}
+	return cf + vf, nil
+}
+
+func isInt(rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return false
+}
+
+func toFloat(rv reflect.Value) (float64, bool) {
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+func toSlice(v interface{}) []interface{} {
+	result := []interface{}{}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		for i := 0; i < rv.Len(); i++ {
+			result = append(result, rv.Index(i).Interface())
+		}
+	}
+	return result
+}
+
+func equalValue(a, b interface{}) bool {
+	af, aok := toFloat(reflect.ValueOf(a))
+	bf, bok := toFloat(reflect.ValueOf(b))
+	if aok && bok {
+		return af == bf
+	}
+	return reflect.DeepEqual(a, b)
+}